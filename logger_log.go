@@ -0,0 +1,13 @@
+//go:build !go1.21
+// +build !go1.21
+
+package main
+
+import (
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+)
+
+// newLogger returns no logger before Go 1.21, which lacks log/slog, so whisper.dll prints to standard error
+func newLogger() whisper.Logger {
+	return nil
+}
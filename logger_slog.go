@@ -0,0 +1,15 @@
+//go:build go1.21
+// +build go1.21
+
+package main
+
+import (
+	"log/slog"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+)
+
+// newLogger passes the messages of whisper.dll to the default slog handler
+func newLogger() whisper.Logger {
+	return whisper.SlogLogger(slog.Default().Handler())
+}
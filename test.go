@@ -18,16 +18,17 @@ func main() {
 	ModelFile := "ggml-medium.bin"
 	AudioFile := "test.wav"
 
-	ok, err := whisper.SetupLogger(whisper.LlDebug, whisper.LfUseStandardError, nil)
-	if !ok {
-		fmt.Printf("Logger Error : %s", err.Error())
+	lib, err := whisper.New(whisper.LlDebug, whisper.LfUseStandardError, newLogger())
+	if err != nil {
+		log.Fatal(err.Error())
 	}
+	fmt.Printf("Loaded %s version %s\n", lib.DLLPath(), lib.Version())
 
 	// Load Model
 	// -----------------------------------------------------------------
-	model, err := whisper.LoadWhisperModel(ModelFile)
+	model, err := lib.LoadModel(ModelFile)
 	if err != nil {
-		fmt.Print(err.Error())
+		log.Fatal(err.Error())
 	}
 	fmt.Printf("Loaded Whisper Model : %s\n", ModelFile)
 
@@ -47,7 +48,7 @@ func main() {
 
 	// init Media Foundation
 	// -----------------------------------------------------------------
-	mf, err := whisper.DoinitMediaFoundation()
+	mf, err := lib.InitMediaFoundation()
 	if err == nil {
 		fmt.Println("MediaFoundations Initialised")
 	}
@@ -92,30 +93,33 @@ func main() {
 }
 
 func newSegmentCallback(context *whisper.IContext, n_new uint32, user_data unsafe.Pointer) uintptr {
-	results := &whisper.ITranscribeResult{}
+	var results *whisper.ITranscribeResult
 
-	context.GetResults(whisper.RfTokens|whisper.RfTimestamps, &results)
+	if ret := context.GetResults(whisper.RfTokens|whisper.RfTimestamps, &results); ret != 0 || results == nil {
+		return ret
+	}
+	defer results.Release()
 
-	length, err := results.GetSize()
+	segments, tokens, err := results.Contents()
 	if err != nil {
 		log.Print(err.Error())
+		return 0
 	}
 
 	// print the last n_new segments
-	s0 := uint32(length.CountSegments - n_new)
+	s0 := uint32(0)
+	if n_new < uint32(len(segments)) {
+		s0 = uint32(len(segments)) - n_new
+	}
 
 	if n_new == 1 { // if s0 == 0 {  // newline on new segment works beter for me
 		fmt.Printf("\n")
 	}
 
-	segments := results.GetSegments(length.CountSegments)
-	tokens := results.GetTokens(999) // todo... we dont want to hard code 999 how can we do better
-
-	for i := s0; i < length.CountSegments; i++ {
-		seg := segments[i]
+	for i := s0; i < uint32(len(segments)); i++ {
+		segTokens, _ := segments[i].Tokens(tokens)
 
-		for j := uint32(0); j < seg.CountTokens; j++ {
-			tok := tokens[seg.FirstToken+j]
+		for _, tok := range segTokens {
 
 			if (tok.Flags & whisper.TfSpecial) == 0 {
 				fmt.Printf("%s%s%s", k_colors[colorIndex(tok)], tok.Text(), "\033[0m")
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+import (
+	"time"
+)
+
+func NewAudioBuffer(pcm *PcmBuffer, start time.Duration) (*iAudioBuffer, error) {
+	return nil, errUnsupported("NewAudioBuffer")
+}
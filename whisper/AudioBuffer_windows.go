@@ -0,0 +1,138 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// An iAudioBuffer COM object implemented in Go, for PCM which didn't come from Media Foundation.
+//
+// The object is a goComObject like the other COM objects implemented in Go. The native code keeps
+// the sample pointers for as long as it holds a reference, so the samples live in LocalAlloc memory
+// instead of the Go heap, freed with the object by the last Release.
+
+type audioBufferState struct {
+	count   uint32
+	samples uintptr // LocalAlloc memory, mono then stereo
+	mono    uintptr
+	stereo  uintptr // 0 without stereo
+	time    int64   // 100ns ticks
+}
+
+func (this *audioBufferState) final() {
+	if this.samples != 0 {
+		windows.LocalFree(windows.Handle(this.samples))
+		this.samples, this.mono, this.stereo = 0, 0, 0
+	}
+}
+
+func goAudioBufferState(this *goComObject) (*audioBufferState, bool) {
+	state, ok := this.state().(*audioBufferState)
+	return state, ok
+}
+
+var goAudioBufferVtbl = iAudioBufferVtbl{
+	QueryInterface: syscall.NewCallback(func(this *goComObject, riid *windows.GUID, ppv *uintptr) uintptr {
+		return this.queryInterface(riid, ppv, nil)
+	}),
+	AddRef: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.addRef()
+	}),
+	Release: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.release()
+	}),
+
+	// uint32_t countSamples() const
+	countSamples: syscall.NewCallback(func(this *goComObject) uintptr {
+		if state, ok := goAudioBufferState(this); ok {
+			return uintptr(state.count)
+		}
+		return 0
+	}),
+
+	// const float* getPcmMono() const
+	getPcmMono: syscall.NewCallback(func(this *goComObject) uintptr {
+		if state, ok := goAudioBufferState(this); ok {
+			return state.mono
+		}
+		return 0
+	}),
+
+	// const float* getPcmStereo() const
+	getPcmStereo: syscall.NewCallback(func(this *goComObject) uintptr {
+		if state, ok := goAudioBufferState(this); ok {
+			return state.stereo
+		}
+		return 0
+	}),
+
+	// HRESULT getTime( int64_t& rdi ) const
+	getTime: syscall.NewCallback(func(this *goComObject, rdi *int64) uintptr {
+		state, ok := goAudioBufferState(this)
+		if !ok || rdi == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		*rdi = state.time
+		return uintptr(windows.S_OK)
+	}),
+}
+
+// NewAudioBuffer copies 16 kHz PCM into an iAudioBuffer which RunFull accepts like one from LoadAudioFile.
+// Stereo is optional; when present it must hold 2 interleaved samples per mono sample.
+// start is returned by getTime. The buffer is created with one reference, which the caller must Release.
+func NewAudioBuffer(pcm *PcmBuffer, start time.Duration) (*iAudioBuffer, error) {
+	if pcm == nil {
+		return nil, errors.New("NewAudioBuffer: pcm is nil")
+	}
+
+	count := len(pcm.Mono)
+	if count > math.MaxUint32 {
+		return nil, fmt.Errorf("NewAudioBuffer: %d samples is too many", count)
+	}
+	if len(pcm.Stereo) != 0 && len(pcm.Stereo) != 2*count {
+		return nil, fmt.Errorf("NewAudioBuffer: %d stereo samples for %d mono samples", len(pcm.Stereo), count)
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("NewAudioBuffer: start %v is negative", start)
+	}
+
+	state := &audioBufferState{
+		count: uint32(count),
+		time:  int64(start / 100),
+	}
+
+	floats := count + len(pcm.Stereo)
+	if floats > 0 {
+		if uint64(floats)*4 > math.MaxUint32 {
+			return nil, fmt.Errorf("NewAudioBuffer: %d samples is too many", floats)
+		}
+		addr, err := windows.LocalAlloc(0, uint32(floats*4))
+		if err != nil {
+			return nil, fmt.Errorf("NewAudioBuffer: %w", err)
+		}
+
+		// LocalAlloc memory is outside of the Go heap
+		samples := unsafe.Slice((*float32)(syscallPointer(addr)), floats)
+		copy(samples, pcm.Mono)
+		copy(samples[count:], pcm.Stereo)
+
+		state.samples = addr
+		state.mono = addr
+		if len(pcm.Stereo) > 0 {
+			state.stereo = addr + uintptr(count)*4
+		}
+	}
+
+	obj, err := newGoComObject(unsafe.Pointer(&goAudioBufferVtbl), state)
+	if err != nil {
+		state.final()
+		return nil, fmt.Errorf("NewAudioBuffer: %w", err)
+	}
+	return (*iAudioBuffer)(unsafe.Pointer(obj)), nil
+}
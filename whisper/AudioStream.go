@@ -0,0 +1,64 @@
+package whisper
+
+import (
+	"errors"
+	"io"
+)
+
+// An iAudioReader implemented in Go, so RunStreamed can read audio from any Go source.
+// getReader returns a Go implementation of IMFSourceReader, which delivers 16 kHz float PCM
+// one block at a time: at most streamBlockSamples are held in memory, however long the stream is.
+
+// PcmSource produces 16 kHz PCM, WavReader implements it
+type PcmSource interface {
+	// ReadPcm reads up to len(mono) samples, and when stereo is not nil the same samples as interleaved stereo.
+	// Returns io.EOF after the last sample.
+	ReadPcm(mono []float32, stereo []float32) (int, error)
+}
+
+// One second per IMFSample
+const streamBlockSamples = SampleRate
+
+// skipPcm reads and discards samples from the source
+func skipPcm(source PcmSource, samples int64) error {
+	buffer := make([]float32, streamBlockSamples)
+	for samples > 0 {
+		n := len(buffer)
+		if int64(n) > samples {
+			n = int(samples)
+		}
+		read, err := source.ReadPcm(buffer[:n], nil)
+		samples -= int64(read)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reader returns the iAudioReader for RunStreamed
+func (this *AudioStream) Reader() *iAudioReader {
+	return this.reader
+}
+
+// RunStream runs RunStreamed over the stream.
+// When the source fails, its error is returned rather than the HRESULT the native code reported for it.
+func (this *IContext) RunStream(params *FullParams, stream *AudioStream) error {
+	return this.RunStreamProgress(params, stream, nil)
+}
+
+// RunStreamProgress is RunStream reporting the progress, which may be nil
+func (this *IContext) RunStreamProgress(params *FullParams, stream *AudioStream, progress *ProgressReporter) error {
+	if stream == nil || stream.reader == nil {
+		return errors.New("RunStream: stream is nil or closed")
+	}
+
+	err := this.RunStreamedProgress(params, stream.reader, progress)
+	if serr := stream.Err(); serr != nil {
+		return serr
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+import (
+	"io"
+	"time"
+)
+
+// AudioStream can't be created on this platform
+type AudioStream struct {
+	reader *iAudioReader
+}
+
+func NewAudioStream(source PcmSource, stereo bool, duration time.Duration) (*AudioStream, error) {
+	return nil, errUnsupported("NewAudioStream")
+}
+
+func NewWavStream(r io.Reader, stereo bool) (*AudioStream, error) {
+	return nil, errUnsupported("NewWavStream")
+}
+
+func (this *AudioStream) Err() error {
+	return nil
+}
+
+func (this *AudioStream) Close() error {
+	return nil
+}
@@ -0,0 +1,500 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// AudioStream owns an iAudioReader reading from a PcmSource
+type AudioStream struct {
+	reader *iAudioReader
+	state  *audioStreamState
+}
+
+type audioStreamState struct {
+	mutex sync.Mutex
+
+	source   PcmSource
+	stereo   bool
+	duration time.Duration
+	// Optional, restarts the source at the sample
+	seek func(sample int64) (PcmSource, error)
+
+	// Channels of the current media type
+	channels uint32
+	// Samples delivered so far
+	position int64
+	eof      bool
+	err      error
+
+	mono, pair []float32
+
+	// Created by the first getReader, the state holds one reference
+	sourceReader *goComObject
+}
+
+// NewAudioStream creates an iAudioReader for RunStreamed, reading from the source.
+// duration is reported to the native code, 0 when unknown.
+// The stream must be closed once RunStreamed returned.
+func NewAudioStream(source PcmSource, stereo bool, duration time.Duration) (*AudioStream, error) {
+	if source == nil {
+		return nil, errors.New("NewAudioStream: source is nil")
+	}
+	state := &audioStreamState{
+		source:   source,
+		stereo:   stereo,
+		duration: duration,
+		channels: 1,
+	}
+	if stereo {
+		state.channels = 2
+	}
+	return newAudioStream(state)
+}
+
+// NewWavStream creates an iAudioReader for RunStreamed, decoding a WAV stream with WavReader.
+// When r is an io.ReadSeeker the native code can seek the stream.
+func NewWavStream(r io.Reader, stereo bool) (*AudioStream, error) {
+	wav, err := NewWavReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &audioStreamState{
+		source:   wav,
+		stereo:   stereo,
+		duration: wav.Duration(),
+		channels: 1,
+	}
+	if stereo {
+		state.channels = 2
+	}
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		state.seek = func(sample int64) (PcmSource, error) {
+			if _, err := rs.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			wav, err := NewWavReader(rs)
+			if err != nil {
+				return nil, err
+			}
+			if err := skipPcm(wav, sample); err != nil {
+				return nil, err
+			}
+			return wav, nil
+		}
+	}
+
+	return newAudioStream(state)
+}
+
+func newAudioStream(state *audioStreamState) (*AudioStream, error) {
+	obj, err := newGoComObject(unsafe.Pointer(&goAudioReaderVtbl), state)
+	if err != nil {
+		return nil, err
+	}
+	return &AudioStream{reader: (*iAudioReader)(unsafe.Pointer(obj)), state: state}, nil
+}
+
+// Err returns the error of the source which stopped the stream, nil after a clean io.EOF
+func (this *AudioStream) Err() error {
+	this.state.mutex.Lock()
+	defer this.state.mutex.Unlock()
+
+	return this.state.err
+}
+
+// Close releases the reference of the stream, the native code may still hold its own
+func (this *AudioStream) Close() error {
+	if this.reader == nil {
+		return nil
+	}
+	this.reader.Release()
+	this.reader = nil
+	return nil
+}
+
+// goAudioReaderPosition returns the position of a reader created by NewAudioStream, nil for other readers
+func goAudioReaderPosition(reader *iAudioReader) func() time.Duration {
+	if reader == nil || reader.lpVtbl != &goAudioReaderVtbl {
+		return nil
+	}
+	state, ok := goAudioReaderState((*goComObject)(unsafe.Pointer(reader)))
+	if !ok {
+		return nil
+	}
+
+	return func() time.Duration {
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		return time.Duration(state.ticks(state.position)) * 100
+	}
+}
+
+// final releases the source reader when the last reference of the iAudioReader is gone
+func (this *audioStreamState) final() {
+	this.mutex.Lock()
+	reader := this.sourceReader
+	this.sourceReader = nil
+	this.mutex.Unlock()
+
+	if reader != nil {
+		reader.release()
+	}
+}
+
+func (this *audioStreamState) getReader() (*goComObject, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.sourceReader == nil {
+		obj, err := newGoComObject(unsafe.Pointer(&goSourceReaderVtbl), &sourceReaderState{stream: this})
+		if err != nil {
+			return nil, err
+		}
+		this.sourceReader = obj
+	}
+	this.sourceReader.addRef()
+	return this.sourceReader, nil
+}
+
+// readSample creates the IMFSample of the next block, or returns nil at the end of the stream
+func (this *audioStreamState) readSample() (unsafe.Pointer, int64, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.err != nil {
+		return nil, 0, this.err
+	}
+
+	if this.mono == nil {
+		this.mono = make([]float32, streamBlockSamples)
+	}
+	var pair []float32
+	if this.channels == 2 {
+		if this.pair == nil {
+			this.pair = make([]float32, streamBlockSamples*2)
+		}
+		pair = this.pair
+	}
+
+	n := 0
+	for n == 0 && !this.eof {
+		read, err := this.source.ReadPcm(this.mono, pair)
+		n = read
+		if err == io.EOF {
+			this.eof = true
+		} else if err != nil {
+			this.err = err
+			return nil, 0, err
+		}
+	}
+	if n == 0 {
+		return nil, this.ticks(this.position), nil
+	}
+
+	data := this.mono[:n]
+	if this.channels == 2 {
+		data = pair[:n*2]
+	}
+
+	start := this.ticks(this.position)
+	sample, err := mfCreateSample(data, start, this.ticks(this.position+int64(n))-start)
+	if err != nil {
+		this.err = fmt.Errorf("MFCreateSample: %w", err)
+		return nil, 0, this.err
+	}
+	this.position += int64(n)
+	return sample, start, nil
+}
+
+// setPosition seeks to the time in 100ns ticks
+func (this *audioStreamState) setPosition(ticks int64) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.seek == nil {
+		return errors.New("the source can't seek")
+	}
+
+	sample := ticks * SampleRate / 10000000
+	source, err := this.seek(sample)
+	if err != nil {
+		this.err = err
+		return err
+	}
+
+	this.source = source
+	this.position = sample
+	this.eof = false
+	return nil
+}
+
+func (this *audioStreamState) ticks(samples int64) int64 {
+	return samples * 10000000 / SampleRate
+}
+
+// ************************************************************
+
+func goAudioReaderState(this *goComObject) (*audioStreamState, bool) {
+	state, ok := this.state().(*audioStreamState)
+	return state, ok
+}
+
+var goAudioReaderVtbl = iAudioReaderVtbl{
+	QueryInterface: syscall.NewCallback(func(this *goComObject, riid *windows.GUID, ppv *uintptr) uintptr {
+		return this.queryInterface(riid, ppv, nil)
+	}),
+	AddRef: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.addRef()
+	}),
+	Release: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.release()
+	}),
+
+	// HRESULT getDuration( int64_t& rdi ) const
+	getDuration: syscall.NewCallback(func(this *goComObject, rdi *int64) uintptr {
+		state, ok := goAudioReaderState(this)
+		if !ok || rdi == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		*rdi = int64(state.duration / 100)
+		return uintptr(windows.S_OK)
+	}),
+
+	// HRESULT getReader( IMFSourceReader** pp ) const
+	getReader: syscall.NewCallback(func(this *goComObject, pp *uintptr) uintptr {
+		state, ok := goAudioReaderState(this)
+		if !ok || pp == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		reader, err := state.getReader()
+		if err != nil {
+			return uintptr(windows.E_OUTOFMEMORY)
+		}
+		*pp = uintptr(unsafe.Pointer(reader))
+		return uintptr(windows.S_OK)
+	}),
+
+	// HRESULT requestedStereo() const
+	requestedStereo: syscall.NewCallback(func(this *goComObject) uintptr {
+		if state, ok := goAudioReaderState(this); ok && state.stereo {
+			return uintptr(S_OK)
+		}
+		return uintptr(S_FALSE)
+	}),
+}
+
+// ************************************************************
+
+// mfreadwrite.h
+type iMFSourceReaderVtbl struct {
+	QueryInterface           uintptr
+	AddRef                   uintptr
+	Release                  uintptr
+	GetStreamSelection       uintptr // ( DWORD dwStreamIndex, BOOL* pfSelected )
+	SetStreamSelection       uintptr // ( DWORD dwStreamIndex, BOOL fSelected )
+	GetNativeMediaType       uintptr // ( DWORD dwStreamIndex, DWORD dwMediaTypeIndex, IMFMediaType** ppMediaType )
+	GetCurrentMediaType      uintptr // ( DWORD dwStreamIndex, IMFMediaType** ppMediaType )
+	SetCurrentMediaType      uintptr // ( DWORD dwStreamIndex, DWORD* pdwReserved, IMFMediaType* pMediaType )
+	SetCurrentPosition       uintptr // ( REFGUID guidTimeFormat, REFPROPVARIANT varPosition )
+	ReadSample               uintptr // ( DWORD dwStreamIndex, DWORD dwControlFlags, DWORD* pdwActualStreamIndex, DWORD* pdwStreamFlags, LONGLONG* pllTimestamp, IMFSample** ppSample )
+	Flush                    uintptr // ( DWORD dwStreamIndex )
+	GetServiceForStream      uintptr // ( DWORD dwStreamIndex, REFGUID guidService, REFIID riid, LPVOID* ppvObject )
+	GetPresentationAttribute uintptr // ( DWORD dwStreamIndex, REFGUID guidAttribute, PROPVARIANT* pvarAttribute )
+}
+
+type sourceReaderState struct {
+	stream *audioStreamState
+}
+
+func goSourceReaderStream(this *goComObject) *audioStreamState {
+	if state, ok := this.state().(*sourceReaderState); ok {
+		return state.stream
+	}
+	return nil
+}
+
+// The only stream is the first audio stream, index 0
+func isAudioStream(index uintptr) bool {
+	index = uintptr(uint32(index))
+	return index == 0 || index == mfSourceReaderFirstAudioStream
+}
+
+var goSourceReaderVtbl = iMFSourceReaderVtbl{
+	QueryInterface: syscall.NewCallback(func(this *goComObject, riid *windows.GUID, ppv *uintptr) uintptr {
+		return this.queryInterface(riid, ppv, &iidIMFSourceReader)
+	}),
+	AddRef: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.addRef()
+	}),
+	Release: syscall.NewCallback(func(this *goComObject) uintptr {
+		return this.release()
+	}),
+
+	GetStreamSelection: syscall.NewCallback(func(this *goComObject, index uintptr, selected *int32) uintptr {
+		if selected == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if !isAudioStream(index) {
+			return mfEInvalidStreamNumber
+		}
+		*selected = 1
+		return uintptr(windows.S_OK)
+	}),
+
+	SetStreamSelection: syscall.NewCallback(func(this *goComObject, index uintptr, selected uintptr) uintptr {
+		index = uintptr(uint32(index))
+		if !isAudioStream(index) && index != mfSourceReaderAllStreams {
+			return mfEInvalidStreamNumber
+		}
+		return uintptr(windows.S_OK)
+	}),
+
+	GetNativeMediaType: syscall.NewCallback(func(this *goComObject, index uintptr, typeIndex uintptr, pp *unsafe.Pointer) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || pp == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if !isAudioStream(index) {
+			return mfEInvalidStreamNumber
+		}
+		if uint32(typeIndex) != 0 {
+			return mfENoMoreTypes
+		}
+
+		channels := uint32(1)
+		if stream.stereo {
+			channels = 2
+		}
+		mt, err := mfCreateFloatMediaType(channels)
+		if err != nil {
+			return uintptr(windows.E_OUTOFMEMORY)
+		}
+		*pp = mt
+		return uintptr(windows.S_OK)
+	}),
+
+	GetCurrentMediaType: syscall.NewCallback(func(this *goComObject, index uintptr, pp *unsafe.Pointer) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || pp == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if !isAudioStream(index) {
+			return mfEInvalidStreamNumber
+		}
+
+		stream.mutex.Lock()
+		channels := stream.channels
+		stream.mutex.Unlock()
+
+		mt, err := mfCreateFloatMediaType(channels)
+		if err != nil {
+			return uintptr(windows.E_OUTOFMEMORY)
+		}
+		*pp = mt
+		return uintptr(windows.S_OK)
+	}),
+
+	// Only 16 kHz float PCM is available, mono or stereo
+	SetCurrentMediaType: syscall.NewCallback(func(this *goComObject, index uintptr, reserved uintptr, mt unsafe.Pointer) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || mt == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if !isAudioStream(index) {
+			return mfEInvalidStreamNumber
+		}
+
+		channels := mfFloatChannels(mt)
+		if channels != 1 && channels != 2 {
+			return mfEInvalidMediaType
+		}
+
+		stream.mutex.Lock()
+		stream.channels = channels
+		stream.mutex.Unlock()
+		return uintptr(windows.S_OK)
+	}),
+
+	SetCurrentPosition: syscall.NewCallback(func(this *goComObject, format *windows.GUID, position *propVariant) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || format == nil || position == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		// GUID_NULL is 100ns units
+		if *format != (windows.GUID{}) || position.vt != vtI8 {
+			return uintptr(windows.E_INVALIDARG)
+		}
+		if stream.seek == nil {
+			return eNotImpl
+		}
+		if err := stream.setPosition(position.val); err != nil {
+			return eFail
+		}
+		return uintptr(windows.S_OK)
+	}),
+
+	ReadSample: syscall.NewCallback(func(this *goComObject, index uintptr, control uintptr, actualIndex *uint32, flags *uint32, timestamp *int64, pp *unsafe.Pointer) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || pp == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if !isAudioStream(index) {
+			return mfEInvalidStreamNumber
+		}
+
+		sample, start, err := stream.readSample()
+		if err != nil {
+			return eFail
+		}
+
+		if actualIndex != nil {
+			*actualIndex = 0
+		}
+		if flags != nil {
+			*flags = 0
+			if sample == nil {
+				*flags = mfSourceReaderfEndOfStream
+			}
+		}
+		if timestamp != nil {
+			*timestamp = start
+		}
+		*pp = sample
+		return uintptr(windows.S_OK)
+	}),
+
+	Flush: syscall.NewCallback(func(this *goComObject, index uintptr) uintptr {
+		return uintptr(windows.S_OK)
+	}),
+
+	GetServiceForStream: syscall.NewCallback(func(this *goComObject, index uintptr, service *windows.GUID, riid *windows.GUID, ppv *uintptr) uintptr {
+		if ppv != nil {
+			*ppv = 0
+		}
+		return mfEUnsupportedService
+	}),
+
+	GetPresentationAttribute: syscall.NewCallback(func(this *goComObject, index uintptr, attribute *windows.GUID, value *propVariant) uintptr {
+		stream := goSourceReaderStream(this)
+		if stream == nil || attribute == nil || value == nil {
+			return uintptr(windows.E_POINTER)
+		}
+		if uint32(index) != mfSourceReaderMediaSource || *attribute != mfPDDuration || stream.duration <= 0 {
+			return mfEAttributeNotFound
+		}
+
+		*value = propVariant{vt: vtUI8, val: int64(stream.duration / 100)}
+		return uintptr(windows.S_OK)
+	}),
+}
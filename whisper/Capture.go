@@ -0,0 +1,182 @@
+package whisper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// https://github.com/Const-me/Whisper/blob/843a2a6ca6ea47c5ac4889a281badfc808d0ea01/Whisper/API/sFullParams.h
+
+// CaptureStatus - eCaptureStatus, a combination of the flags
+type CaptureStatus uint8
+
+const (
+	CaptureListening    CaptureStatus = 1
+	CaptureVoice        CaptureStatus = 2
+	CaptureTranscribing CaptureStatus = 4
+	CaptureStalled      CaptureStatus = 0x80
+)
+
+func (this CaptureStatus) String() string {
+	if this == 0 {
+		return "none"
+	}
+
+	names := []struct {
+		flag CaptureStatus
+		name string
+	}{
+		{CaptureListening, "listening"},
+		{CaptureVoice, "voice"},
+		{CaptureTranscribing, "transcribing"},
+		{CaptureStalled, "stalled"},
+	}
+
+	var parts []string
+	rest := this
+	for _, n := range names {
+		if this&n.flag != 0 {
+			parts = append(parts, n.name)
+			rest &^= n.flag
+		}
+	}
+	if rest != 0 {
+		parts = append(parts, fmt.Sprintf("0x%X", uint8(rest)))
+	}
+	return strings.Join(parts, "|")
+}
+
+// sCaptureCallbacks - sFullParams.h
+type sCaptureCallbacks struct {
+	shouldCancel  uintptr // HRESULT( __stdcall* )( void* pv ): S_OK to stop, S_FALSE to continue
+	captureStatus uintptr // HRESULT( __stdcall* )( void* pv, eCaptureStatus status )
+	pv            uintptr
+}
+
+// CaptureOptions are the Go functions called while RunCapture runs.
+// They are called on the thread of the capture, one at a time, and should return quickly.
+type CaptureOptions struct {
+	// Results requested for the new segments
+	Flags eResultFlags
+
+	// Called when the status changes
+	OnStatus func(status CaptureStatus)
+	// Called with the segments of every transcribed phrase, as they are produced.
+	// Returning an error stops the capture, and RunCapture returns the error.
+	OnSegments func(segments []Segment) error
+}
+
+// captureSession is the Go state of one capture.
+// The native callbacks only forward to it, so a fake captureSource can drive it the same way.
+type captureSession struct {
+	ctx     context.Context
+	options CaptureOptions
+
+	mutex  sync.Mutex
+	status CaptureStatus
+	err    error
+}
+
+// captureSource runs a capture until the session asks to stop
+type captureSource interface {
+	run(session *captureSession) error
+}
+
+func newCaptureSession(ctx context.Context, options CaptureOptions) *captureSession {
+	return &captureSession{ctx: ctx, options: options}
+}
+
+// shouldCancel is true once the context is done, or a handler failed
+func (this *captureSession) shouldCancel() bool {
+	this.mutex.Lock()
+	failed := this.err != nil
+	this.mutex.Unlock()
+
+	return failed || this.ctx.Err() != nil
+}
+
+func (this *captureSession) setStatus(status CaptureStatus) {
+	this.mutex.Lock()
+	changed := status != this.status
+	this.status = status
+	this.mutex.Unlock()
+
+	if changed && this.options.OnStatus != nil {
+		this.options.OnStatus(status)
+	}
+}
+
+func (this *captureSession) lastStatus() CaptureStatus {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.status
+}
+
+func (this *captureSession) newSegments(segments []Segment) {
+	if len(segments) == 0 || this.options.OnSegments == nil || this.shouldCancel() {
+		return
+	}
+
+	if err := this.options.OnSegments(segments); err != nil {
+		this.fail(err)
+	}
+}
+
+// fail records the first error, and cancels the capture
+func (this *captureSession) fail(err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.err == nil {
+		this.err = err
+	}
+}
+
+// run drives the source, and returns why the capture stopped: the first handler error, the context error,
+// or the error of the source. A capture cancelled by the context returns the context error.
+func (this *captureSession) run(source captureSource) error {
+	if err := this.ctx.Err(); err != nil {
+		return err
+	}
+
+	runErr := source.run(this)
+
+	this.mutex.Lock()
+	err := this.err
+	this.mutex.Unlock()
+
+	switch {
+	case err != nil:
+		return err
+	case this.ctx.Err() != nil:
+		return this.ctx.Err()
+	}
+	return runErr
+}
+
+// ************************************************************
+
+// nativeCapture runs iContext.runCapture
+type nativeCapture struct {
+	context *IContext
+	params  *FullParams
+	capture *iAudioCapture
+}
+
+// RunCapture transcribes the audio of a capture device until ctx is done, delivering segments to options.OnSegments.
+// It blocks while capturing, and returns ctx.Err() when cancelled by the context.
+func (this *IContext) RunCapture(ctx context.Context, params *FullParams, capture *iAudioCapture, options CaptureOptions) error {
+	if err := params.check(); err != nil {
+		return err
+	}
+	if capture == nil {
+		return errors.New("RunCapture: capture is nil")
+	}
+
+	session := newCaptureSession(ctx, options)
+	return session.run(&nativeCapture{context: this, params: params, capture: capture})
+}
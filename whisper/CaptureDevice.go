@@ -0,0 +1,91 @@
+package whisper
+
+import (
+	"fmt"
+	"time"
+)
+
+// https://github.com/Const-me/Whisper/blob/843a2a6ca6ea47c5ac4889a281badfc808d0ea01/Whisper/API/iMediaFoundation.h
+
+// sCaptureDevice - iMediaFoundation.h
+type sCaptureDevice struct {
+	displayName *uint16 // const wchar_t*
+	endpoint    *uint16 // const wchar_t*
+}
+
+// CaptureDevice is an audio capture device
+type CaptureDevice struct {
+	// Suitable for showing to the user, but might not be unique
+	DisplayName string
+	// Endpoint ID to pass to OpenCaptureDevice
+	Endpoint string
+}
+
+type eCaptureFlags uint32
+
+const (
+	CaptureFlagNone   eCaptureFlags = 0
+	CaptureFlagStereo eCaptureFlags = 1
+)
+
+// CaptureParams mirror sCaptureParams
+type CaptureParams struct {
+	// Audio captured before the first transcription, and between the following ones while speech continues
+	MinDuration time.Duration
+	// The audio is transcribed once this long, even without a pause
+	MaxDuration time.Duration
+	// Silence at the start of the capture which is dropped
+	DropStartSilence time.Duration
+	// A pause this long ends a phrase, and triggers transcription
+	PauseDuration time.Duration
+	Flags         eCaptureFlags
+}
+
+// DefaultCaptureParams returns the defaults of sCaptureParams
+func DefaultCaptureParams() CaptureParams {
+	return CaptureParams{
+		MinDuration:      7 * time.Second,
+		MaxDuration:      11 * time.Second,
+		DropStartSilence: 250 * time.Millisecond,
+		PauseDuration:    333 * time.Millisecond,
+		Flags:            CaptureFlagNone,
+	}
+}
+
+func (this *CaptureParams) validate() error {
+	switch {
+	case this.MinDuration <= 0:
+		return fmt.Errorf("capture MinDuration %v is not positive", this.MinDuration)
+	case this.MaxDuration < this.MinDuration:
+		return fmt.Errorf("capture MaxDuration %v is shorter than MinDuration %v", this.MaxDuration, this.MinDuration)
+	case this.DropStartSilence < 0:
+		return fmt.Errorf("capture DropStartSilence %v is negative", this.DropStartSilence)
+	case this.PauseDuration < 0:
+		return fmt.Errorf("capture PauseDuration %v is negative", this.PauseDuration)
+	}
+	return nil
+}
+
+func (this *CaptureParams) cStruct() sCaptureParams {
+	return sCaptureParams{
+		minDuration:      float32(this.MinDuration.Seconds()),
+		maxDuration:      float32(this.MaxDuration.Seconds()),
+		dropStartSilence: float32(this.DropStartSilence.Seconds()),
+		pauseDuration:    float32(this.PauseDuration.Seconds()),
+		flags:            uint32(this.Flags),
+	}
+}
+
+func newCaptureParams(cs *sCaptureParams) CaptureParams {
+	seconds := func(s float32) time.Duration {
+		return time.Duration(float64(s) * float64(time.Second))
+	}
+
+	return CaptureParams{
+		MinDuration:      seconds(cs.minDuration),
+		MaxDuration:      seconds(cs.maxDuration),
+		DropStartSilence: seconds(cs.dropStartSilence),
+		PauseDuration:    seconds(cs.pauseDuration),
+		Flags:            eCaptureFlags(cs.flags),
+	}
+}
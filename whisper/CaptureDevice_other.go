@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func (this *IMediaFoundation) ListCaptureDevices() ([]CaptureDevice, error) {
+	return nil, errUnsupported("listCaptureDevices")
+}
+
+func (this *IMediaFoundation) OpenCaptureDevice(endpoint string, params CaptureParams) (*iAudioCapture, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	return nil, errUnsupported("openCaptureDevice")
+}
+
+func (this *iAudioCapture) AddRef() int32 {
+	return 0
+}
+
+func (this *iAudioCapture) Release() int32 {
+	return 0
+}
+
+func (this *iAudioCapture) Params() CaptureParams {
+	return CaptureParams{}
+}
@@ -0,0 +1,125 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+/*
+using pfnFoundCaptureDevices = HRESULT( __stdcall* )( int len, const sCaptureDevice* buffer, void* pv );
+*/
+var captureDevicesCallback = syscall.NewCallback(func(length uintptr, buffer *sCaptureDevice, pv uintptr) uintptr {
+	devices, ok := handles.get(pv).(*[]CaptureDevice)
+	if !ok {
+		return uintptr(windows.E_POINTER)
+	}
+	if buffer == nil || int32(length) <= 0 {
+		return uintptr(windows.S_OK)
+	}
+
+	// The strings are only valid during the callback
+	for _, dev := range unsafe.Slice(buffer, int32(length)) {
+		*devices = append(*devices, CaptureDevice{
+			DisplayName: windows.UTF16PtrToString(dev.displayName),
+			Endpoint:    windows.UTF16PtrToString(dev.endpoint),
+		})
+	}
+	return uintptr(windows.S_OK)
+})
+
+// ListCaptureDevices returns the audio capture devices of the computer, empty when there are none
+func (this *IMediaFoundation) ListCaptureDevices() ([]CaptureDevice, error) {
+	if err := requireFeature("ListCaptureDevices", "audio capture", func(caps Capabilities) bool { return caps.Capture }); err != nil {
+		return nil, err
+	}
+
+	devices := []CaptureDevice{}
+	handle := handles.add(&devices)
+	defer handles.remove(handle)
+
+	// listCaptureDevices( pfnFoundCaptureDevices pfn, void* pv );
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.listCaptureDevices,
+		uintptr(unsafe.Pointer(this)),
+		captureDevicesCallback,
+		handle,
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("listCaptureDevices failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	return devices, nil
+}
+
+// OpenCaptureDevice opens the capture device with the endpoint ID of a CaptureDevice.
+// The returned object must be released.
+func (this *IMediaFoundation) OpenCaptureDevice(endpoint string, params CaptureParams) (*iAudioCapture, error) {
+	if err := requireFeature("OpenCaptureDevice", "audio capture", func(caps Capabilities) bool { return caps.Capture }); err != nil {
+		return nil, err
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	UTFEndpoint, err := windows.UTF16PtrFromString(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	cparams := params.cStruct()
+	var capture *iAudioCapture
+
+	// openCaptureDevice( LPCTSTR endpoint, const sCaptureParams& captureParams, iAudioCapture** pp );
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.openCaptureDevice,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(UTFEndpoint)),
+		uintptr(unsafe.Pointer(&cparams)),
+		uintptr(unsafe.Pointer(&capture)))
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("openCaptureDevice failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	if capture == nil {
+		return nil, errors.New("openCaptureDevice did not return a capture")
+	}
+	return capture, nil
+}
+
+func (this *iAudioCapture) AddRef() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.AddRef,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+func (this *iAudioCapture) Release() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.Release,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+// Params returns the capture parameters the device was opened with
+func (this *iAudioCapture) Params() CaptureParams {
+	// const sCaptureParams& getParams() const;
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getParams,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	if ret == 0 {
+		return CaptureParams{}
+	}
+	return newCaptureParams((*sCaptureParams)(syscallPointer(ret)))
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func (this *nativeCapture) run(session *captureSession) error {
+	return errUnsupported("RunCapture")
+}
@@ -0,0 +1,145 @@
+package whisper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeCapture drives a session like runCapture does: a status, then batches of segments
+// until the session asks to stop or the batches run out
+type fakeCapture struct {
+	statuses []CaptureStatus
+	batches  [][]Segment
+	// Keep polling shouldCancel after the batches, like a capture device which stays open
+	endless bool
+	err     error
+
+	delivered int
+	polls     int
+}
+
+func (this *fakeCapture) run(session *captureSession) error {
+	for _, status := range this.statuses {
+		session.setStatus(status)
+	}
+
+	for {
+		this.polls++
+		if session.shouldCancel() {
+			return nil
+		}
+		if this.delivered == len(this.batches) {
+			if this.endless {
+				continue
+			}
+			return this.err
+		}
+		session.newSegments(this.batches[this.delivered])
+		this.delivered++
+	}
+}
+
+func batch(texts ...string) []Segment {
+	segments := make([]Segment, len(texts))
+	for i, text := range texts {
+		segments[i] = Segment{Text: text}
+	}
+	return segments
+}
+
+func TestCaptureDelivers(t *testing.T) {
+	source := &fakeCapture{
+		statuses: []CaptureStatus{CaptureListening, CaptureListening, CaptureListening | CaptureVoice},
+		batches:  [][]Segment{batch(" one"), batch(" two", " three")},
+	}
+
+	var statuses []CaptureStatus
+	var texts []string
+	session := newCaptureSession(context.Background(), CaptureOptions{
+		OnStatus: func(status CaptureStatus) { statuses = append(statuses, status) },
+		OnSegments: func(segments []Segment) error {
+			for _, seg := range segments {
+				texts = append(texts, seg.Text)
+			}
+			return nil
+		},
+	})
+
+	if err := session.run(source); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated statuses are only reported once
+	if len(statuses) != 2 || statuses[1] != CaptureListening|CaptureVoice {
+		t.Fatalf("statuses %v", statuses)
+	}
+	if session.lastStatus() != CaptureListening|CaptureVoice {
+		t.Fatalf("last status %v", session.lastStatus())
+	}
+	if len(texts) != 3 || texts[2] != " three" {
+		t.Fatalf("segments %q", texts)
+	}
+}
+
+func TestCaptureHandlerErrorStops(t *testing.T) {
+	source := &fakeCapture{batches: [][]Segment{batch(" one"), batch(" two"), batch(" three")}, endless: true}
+	failure := errors.New("disk full")
+
+	calls := 0
+	session := newCaptureSession(context.Background(), CaptureOptions{
+		OnSegments: func(segments []Segment) error {
+			calls++
+			return failure
+		},
+	})
+
+	if err := session.run(source); err != failure {
+		t.Fatalf("run returned %v, expected the handler error", err)
+	}
+	if calls != 1 || source.delivered != 1 {
+		t.Fatalf("%d handler calls, %d batches delivered after the error", calls, source.delivered)
+	}
+}
+
+func TestCaptureContextCancels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := &fakeCapture{batches: [][]Segment{batch(" one"), batch(" two")}, endless: true}
+	session := newCaptureSession(ctx, CaptureOptions{
+		OnSegments: func(segments []Segment) error {
+			cancel()
+			return nil
+		},
+	})
+
+	if err := session.run(source); !errors.Is(err, context.Canceled) {
+		t.Fatalf("run returned %v, expected context.Canceled", err)
+	}
+	if source.delivered != 1 {
+		t.Fatalf("%d batches delivered after the cancellation", source.delivered)
+	}
+}
+
+func TestCaptureDoneContextDoesNotStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	source := &fakeCapture{batches: [][]Segment{batch(" one")}}
+	if err := newCaptureSession(ctx, CaptureOptions{}).run(source); !errors.Is(err, context.Canceled) {
+		t.Fatalf("run returned %v, expected context.Canceled", err)
+	}
+	if source.polls != 0 {
+		t.Fatal("the source ran with a done context")
+	}
+}
+
+func TestCaptureSourceError(t *testing.T) {
+	failure := errors.New("device removed")
+	source := &fakeCapture{batches: [][]Segment{batch(" one")}, err: failure}
+
+	if err := newCaptureSession(context.Background(), CaptureOptions{}).run(source); err != failure {
+		t.Fatalf("run returned %v, expected the source error", err)
+	}
+}
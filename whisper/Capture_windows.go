@@ -0,0 +1,81 @@
+package whisper
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var captureShouldCancelCallback = syscall.NewCallback(func(pv uintptr) uintptr {
+	session, ok := handles.get(pv).(*captureSession)
+	if !ok || session.shouldCancel() {
+		return uintptr(S_OK)
+	}
+	return uintptr(S_FALSE)
+})
+
+var captureStatusCallback = syscall.NewCallback(func(pv uintptr, status uintptr) uintptr {
+	if session, ok := handles.get(pv).(*captureSession); ok {
+		session.setStatus(CaptureStatus(uint8(status)))
+	}
+	return uintptr(S_OK)
+})
+
+// using pfnNewSegment = HRESULT( __cdecl* )( iContext* ctx, uint32_t n_new, void* user_data ) noexcept;
+var captureSegmentCallback = syscall.NewCallbackCDecl(func(ctx *IContext, nNew uintptr, pv uintptr) uintptr {
+	session, ok := handles.get(pv).(*captureSession)
+	if !ok {
+		return uintptr(S_OK)
+	}
+
+	// Only the new segments are copied, a long capture accumulates many
+	segments, err := ctx.lastSegments(session.options.Flags, int(uint32(nNew)))
+	if err != nil {
+		session.fail(fmt.Errorf("capture results: %w", err))
+		return uintptr(S_OK)
+	}
+	session.newSegments(segments)
+	return uintptr(S_OK)
+})
+
+func (this *nativeCapture) run(session *captureSession) error {
+	if err := requireFeature("RunCapture", "audio capture", func(caps Capabilities) bool { return caps.Capture }); err != nil {
+		return err
+	}
+
+	handle := handles.add(session)
+	defer handles.remove(handle)
+
+	// The new segment callback of the params delivers the segments, the caller's one is restored afterwards
+	cs := this.params.cStruct
+	prevCallback, prevData := cs.new_segment_callback, cs.new_segment_callback_user_data
+	cs.new_segment_callback = captureSegmentCallback
+	cs.new_segment_callback_user_data = handle
+	defer func() {
+		cs.new_segment_callback, cs.new_segment_callback_user_data = prevCallback, prevData
+	}()
+
+	callbacks := sCaptureCallbacks{
+		shouldCancel:  captureShouldCancelCallback,
+		captureStatus: captureStatusCallback,
+		pv:            handle,
+	}
+
+	// runCapture( const sFullParams& params, const sCaptureCallbacks& callbacks, const iAudioCapture* reader );
+	ret, _, _ := syscall.SyscallN(
+		this.context.lpVtbl.RunCapture,
+		uintptr(unsafe.Pointer(this.context)),
+		uintptr(unsafe.Pointer(cs)),
+		uintptr(unsafe.Pointer(&callbacks)),
+		uintptr(unsafe.Pointer(this.capture)),
+	)
+	runtime.KeepAlive(this.params)
+
+	if windows.Handle(ret) != windows.S_OK {
+		return fmt.Errorf("RunCapture failed: %w", syscall.Errno(ret))
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper/vad"
+)
+
+// Window of the audio transcribed by one run of the model
+type Window struct {
+	Index int
+	Begin time.Duration
+	End   time.Duration
+}
+
+func (this Window) Duration() time.Duration {
+	return this.End - this.Begin
+}
+
+// WindowTranscriber transcribes one window of the audio.
+// The times of the returned transcript are relative to the start of the window.
+type WindowTranscriber interface {
+	TranscribeWindow(window Window) (*Transcript, error)
+}
+
+// ChunkOptions controls how TranscribeChunked splits the audio
+type ChunkOptions struct {
+	// Length of every window but the last
+	Window time.Duration
+	// Audio at the end of a window which is transcribed again at the start of the next one
+	Overlap time.Duration
+
+	// Speech regions of the audio, optional.
+	// When set, windows end in the pauses between regions where possible, and windows without speech are skipped.
+	Regions []vad.Region
+
+	// Called after every window with the transcript stitched so far, e.g. to save a checkpoint.
+	// Stitching the next window can still replace the segments within the last Overlap of this window,
+	// and the segment before them. Returning an error stops the transcription.
+	OnWindow func(window Window, transcript *Transcript) error
+
+	// Reports the progress after every window, optional
+	Progress *ProgressReporter
+}
+
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		Window:  5 * time.Minute,
+		Overlap: 10 * time.Second,
+	}
+}
+
+func (this *ChunkOptions) validate() error {
+	switch {
+	case this.Window < time.Second:
+		return fmt.Errorf("chunk window %v is shorter than 1s", this.Window)
+	case this.Overlap < 0:
+		return fmt.Errorf("chunk overlap %v is negative", this.Overlap)
+	case this.Overlap*2 >= this.Window:
+		return fmt.Errorf("chunk overlap %v is not less than half of the window %v", this.Overlap, this.Window)
+	}
+	return nil
+}
+
+// PlanWindows splits audio of the given length into the windows TranscribeChunked runs
+func PlanWindows(total time.Duration, options ChunkOptions) ([]Window, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	if total <= 0 {
+		return nil, nil
+	}
+
+	var windows []Window
+	begin := time.Duration(0)
+	for {
+		end := begin + options.Window
+		if end >= total {
+			end = total
+		} else if len(options.Regions) > 0 {
+			// Never move the end back into the overlap of the next window, or before the middle of this one
+			end = alignToPause(options.Regions, begin+options.Window/2, end)
+		}
+
+		if len(options.Regions) == 0 || hasSpeech(options.Regions, begin, end) {
+			windows = append(windows, Window{Index: len(windows), Begin: begin, End: end})
+		}
+
+		if end >= total {
+			return windows, nil
+		}
+		begin = end - options.Overlap
+	}
+}
+
+// alignToPause returns the latest time within [min, end] which is not inside a speech region, or end if there is none
+func alignToPause(regions []vad.Region, min, end time.Duration) time.Duration {
+	for i := len(regions) - 1; i >= 0; i-- {
+		r := regions[i]
+		if r.Begin >= end {
+			continue
+		}
+		if r.End <= end {
+			// end itself is in a pause
+			return end
+		}
+		// end is inside the region, try the pause before it
+		if r.Begin >= min {
+			end = r.Begin
+			continue
+		}
+		break
+	}
+	return end
+}
+
+func hasSpeech(regions []vad.Region, begin, end time.Duration) bool {
+	for _, r := range regions {
+		if r.Begin < end && r.End > begin {
+			return true
+		}
+	}
+	return false
+}
+
+// TranscribeChunked transcribes audio of the given length window by window, and stitches the results.
+// The times of the returned transcript are relative to the start of the audio.
+func TranscribeChunked(transcriber WindowTranscriber, total time.Duration, options ChunkOptions) (*Transcript, error) {
+	if transcriber == nil {
+		return nil, errors.New("TranscribeChunked: transcriber is nil")
+	}
+
+	windows, err := PlanWindows(total, options)
+	if err != nil {
+		return nil, err
+	}
+
+	options.Progress.Start()
+	options.Progress.Report(0)
+
+	result := &Transcript{}
+	var prev *Window
+	for i := range windows {
+		window := &windows[i]
+
+		part, err := transcriber.TranscribeWindow(*window)
+		if err != nil {
+			return nil, fmt.Errorf("window %d (%v - %v): %w", window.Index, window.Begin, window.End, err)
+		}
+
+		if part != nil {
+			part.Shift(window.Begin)
+			if prev != nil && prev.End > window.Begin {
+				result.Stitch(part, window.Begin, prev.End)
+			} else {
+				result.Segments = append(result.Segments, part.Segments...)
+			}
+		}
+		prev = window
+
+		if options.OnWindow != nil {
+			if err := options.OnWindow(*window, result); err != nil {
+				return nil, err
+			}
+		}
+
+		if total > 0 {
+			options.Progress.Report(float64(window.End) / float64(total))
+		}
+	}
+
+	options.Progress.Done()
+	return result, nil
+}
+
+// ************************************************************
+
+// ContextWindows transcribes windows of an audio buffer with RunFull, limited by the offset and duration of the params
+type ContextWindows struct {
+	Context *IContext
+	Params  *FullParams
+	Buffer  *iAudioBuffer
+	Flags   eResultFlags
+}
+
+func (this *ContextWindows) TranscribeWindow(window Window) (*Transcript, error) {
+	transcript, err := this.Context.RunSpeechRegions(this.Params, this.Buffer,
+		[]vad.Region{{Begin: window.Begin, End: window.End}}, this.Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	// RunFull times are relative to the start of the buffer
+	transcript.Shift(-window.Begin)
+	return transcript, nil
+}
+
+// Transcribe runs TranscribeChunked over the whole buffer
+func (this *ContextWindows) Transcribe(options ChunkOptions) (*Transcript, error) {
+	if this.Buffer == nil {
+		return nil, errors.New("ContextWindows: buffer is nil")
+	}
+
+	samples, err := this.Buffer.CountSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	total := time.Duration(samples) * time.Second / SampleRate
+	return TranscribeChunked(this, total, options)
+}
+
+// ************************************************************
+
+// PcmWindows transcribes windows of PCM held in Go memory.
+// Only the window being transcribed is copied into a native buffer, see NewAudioBuffer.
+type PcmWindows struct {
+	Context *IContext
+	Params  *FullParams
+	Pcm     *PcmBuffer
+	Flags   eResultFlags
+}
+
+func (this *PcmWindows) check() error {
+	if this.Pcm == nil {
+		return errors.New("PcmWindows: pcm is nil")
+	}
+	if len(this.Pcm.Stereo) != 0 && len(this.Pcm.Stereo) != 2*len(this.Pcm.Mono) {
+		return fmt.Errorf("PcmWindows: %d stereo samples for %d mono samples", len(this.Pcm.Stereo), len(this.Pcm.Mono))
+	}
+	return nil
+}
+
+func (this *PcmWindows) TranscribeWindow(window Window) (*Transcript, error) {
+	if err := this.check(); err != nil {
+		return nil, err
+	}
+	if err := this.Params.check(); err != nil {
+		return nil, err
+	}
+
+	position := func(t time.Duration) int {
+		i := int(t * SampleRate / time.Second)
+		if i > len(this.Pcm.Mono) {
+			i = len(this.Pcm.Mono)
+		}
+		return i
+	}
+	begin, end := position(window.Begin), position(window.End)
+
+	part := &PcmBuffer{Mono: this.Pcm.Mono[begin:end]}
+	if len(this.Pcm.Stereo) != 0 {
+		part.Stereo = this.Pcm.Stereo[begin*2 : end*2]
+	}
+
+	// The buffer starts at time 0, so the results are relative to the window
+	buffer, err := NewAudioBuffer(part, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer buffer.Release()
+
+	// The whole buffer is the window
+	offset, duration := this.Params.Offset(), this.Params.Duration()
+	defer func() {
+		this.Params.SetOffset(offset)
+		this.Params.SetDuration(duration)
+	}()
+	this.Params.SetOffset(0)
+	this.Params.SetDuration(0)
+
+	if err := this.Context.RunFull(this.Params, buffer); err != nil {
+		return nil, err
+	}
+	return this.Context.Transcript(this.Flags)
+}
+
+// Transcribe runs TranscribeChunked over all of the PCM
+func (this *PcmWindows) Transcribe(options ChunkOptions) (*Transcript, error) {
+	if err := this.check(); err != nil {
+		return nil, err
+	}
+	return TranscribeChunked(this, this.Pcm.Duration(), options)
+}
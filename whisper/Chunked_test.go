@@ -0,0 +1,123 @@
+package whisper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptTranscriber returns the segments of the whole audio within every window, like the model would:
+// words are spread evenly over their segment, and only the words within the window are heard
+type scriptTranscriber struct {
+	segments []Segment
+	windows  []Window
+}
+
+func (this *scriptTranscriber) TranscribeWindow(window Window) (*Transcript, error) {
+	this.windows = append(this.windows, window)
+
+	part := &Transcript{}
+	for _, seg := range this.segments {
+		words := strings.Fields(seg.Text)
+		step := (seg.End - seg.Begin) / time.Duration(len(words))
+
+		var heard []string
+		begin, end := time.Duration(-1), time.Duration(0)
+		for i, word := range words {
+			wordBegin := seg.Begin + time.Duration(i)*step
+			if center := wordBegin + step/2; center < window.Begin || center >= window.End {
+				continue
+			}
+			if begin < 0 {
+				begin = wordBegin
+			}
+			end = wordBegin + step
+			heard = append(heard, word)
+		}
+
+		if len(heard) > 0 {
+			part.Segments = append(part.Segments, Segment{
+				Text:  " " + strings.Join(heard, " "),
+				Begin: begin - window.Begin,
+				End:   end - window.Begin,
+			})
+		}
+	}
+	return part, nil
+}
+
+// speech returns segments of 4 distinct words each, every length apart
+func speech(total, length time.Duration) []Segment {
+	var segments []Segment
+	for begin := time.Duration(0); begin+length <= total; begin += length {
+		n := len(segments)
+		segments = append(segments, Segment{
+			Text:  fmt.Sprintf(" w%da w%db w%dc w%dd", n, n, n, n),
+			Begin: begin,
+			End:   begin + length,
+		})
+	}
+	return segments
+}
+
+func TestPlanWindows(t *testing.T) {
+	options := ChunkOptions{Window: 20 * time.Second, Overlap: 4 * time.Second}
+	windows, err := PlanWindows(50*time.Second, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Window{
+		{0, 0, 20 * time.Second},
+		{1, 16 * time.Second, 36 * time.Second},
+		{2, 32 * time.Second, 50 * time.Second},
+	}
+	if fmt.Sprint(windows) != fmt.Sprint(want) {
+		t.Fatalf("windows %v, expected %v", windows, want)
+	}
+
+	options.Overlap = 10 * time.Second
+	if _, err := PlanWindows(50*time.Second, options); err == nil {
+		t.Fatal("an overlap of half the window was accepted")
+	}
+}
+
+func TestTranscribeChunkedDeduplicates(t *testing.T) {
+	// Segments of 3.3s cross every window edge somewhere else
+	total := 66 * time.Second
+	truth := speech(total, 3300*time.Millisecond)
+	transcriber := &scriptTranscriber{segments: truth}
+
+	options := ChunkOptions{Window: 20 * time.Second, Overlap: 5 * time.Second}
+	calls := 0
+	options.OnWindow = func(window Window, transcript *Transcript) error {
+		calls++
+		return nil
+	}
+
+	got, err := TranscribeChunked(transcriber, total, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(transcriber.windows) || calls < 4 {
+		t.Fatalf("%d OnWindow calls for %d windows", calls, len(transcriber.windows))
+	}
+
+	if got.Text() != (&Transcript{Segments: truth}).Text() {
+		t.Fatalf("stitched text\n%q\nexpected\n%q", got.Text(), (&Transcript{Segments: truth}).Text())
+	}
+	for i := range got.Segments {
+		if got.Segments[i].Begin != truth[i].Begin || got.Segments[i].End != truth[i].End {
+			t.Fatalf("segment %d is %v - %v, expected %v - %v", i,
+				got.Segments[i].Begin, got.Segments[i].End, truth[i].Begin, truth[i].End)
+		}
+	}
+}
+
+func TestPcmWindowsStereoLength(t *testing.T) {
+	windows := &PcmWindows{Pcm: &PcmBuffer{Mono: make([]float32, 100), Stereo: make([]float32, 150)}}
+	if _, err := windows.Transcribe(DefaultChunkOptions()); err == nil || !strings.Contains(err.Error(), "stereo") {
+		t.Fatalf("expected a stereo length error, got %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package whisper
 
 import (
-	"syscall"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
 	"unsafe"
 )
 
@@ -40,6 +44,14 @@ const (
 
 type FullParams struct {
 	cStruct *_FullParams
+
+	// The context which created the params, used to tokenize the prompt
+	context *IContext
+
+	// The prompt tokens, and their native copy referenced by cStruct.prompt_tokens.
+	// The copy is freed when the prompt is replaced, or by the finalizer of the params.
+	prompt       []int32
+	promptTokens uintptr
 }
 
 func (this *FullParams) CpuThreads() int32 {
@@ -52,18 +64,47 @@ func (this *FullParams) CpuThreads() int32 {
 	return this.cStruct.cpuThreads
 }
 
-func (this *FullParams) setCpuThreads(val int32) {
-	if this == nil {
-		return
-	} else if this.cStruct == nil {
-		return
+func (this *FullParams) SetCpuThreads(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 {
+		return fmt.Errorf("SetCpuThreads: %d threads is negative", val)
 	}
 
 	this.cStruct.cpuThreads = val
+	return nil
+}
+
+func (this *FullParams) MaxTextCTX() int32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.n_max_text_ctx
 }
 
-func (this *FullParams) SetMaxTextCTX(val int32) {
+func (this *FullParams) SetMaxTextCTX(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 {
+		return fmt.Errorf("SetMaxTextCTX: %d is negative", val)
+	}
+
 	this.cStruct.n_max_text_ctx = val
+	return nil
+}
+
+func (this *FullParams) Flags() eFullParamsFlags {
+	if this.check() != nil {
+		return FlagNone
+	}
+	return this.cStruct.Flags
+}
+
+// HasFlags is true when every flag in flags is set
+func (this *FullParams) HasFlags(flags eFullParamsFlags) bool {
+	return (this.Flags() & flags) == flags
 }
 
 func (this *FullParams) AddFlags(newflag eFullParamsFlags) {
@@ -76,6 +117,7 @@ func (this *FullParams) AddFlags(newflag eFullParamsFlags) {
 	this.cStruct.Flags = this.cStruct.Flags | newflag
 }
 
+// RemoveFlags clears the flags, flags which are not set stay cleared
 func (this *FullParams) RemoveFlags(newflag eFullParamsFlags) {
 	if this == nil {
 		return
@@ -83,7 +125,388 @@ func (this *FullParams) RemoveFlags(newflag eFullParamsFlags) {
 		return
 	}
 
-	this.cStruct.Flags = this.cStruct.Flags ^ newflag
+	this.cStruct.Flags = this.cStruct.Flags &^ newflag
+}
+
+func (this *FullParams) Strategy() eSamplingStrategy {
+	if this.check() != nil {
+		return SsINVALIDARG
+	}
+	return this.cStruct.strategy
+}
+
+// SetStrategy switches the sampling strategy.
+// The settings of the other strategy are reset to the values FullDefaultParams uses, and when switching to
+// beam search without a beam width the default width of 10 and 5 best candidates are used.
+func (this *FullParams) SetStrategy(strategy eSamplingStrategy) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+
+	switch strategy {
+	case SsGreedy:
+		this.cStruct.beam_search.n_past = -1
+		this.cStruct.beam_search.beam_width = -1
+		this.cStruct.beam_search.n_best = -1
+		if this.cStruct.greedy.n_past < 0 {
+			this.cStruct.greedy.n_past = 0
+		}
+
+	case SsBeamSearch:
+		this.cStruct.greedy.n_past = -1
+		if this.cStruct.beam_search.n_past < 0 {
+			this.cStruct.beam_search.n_past = 0
+		}
+		if this.cStruct.beam_search.beam_width <= 0 || this.cStruct.beam_search.n_best <= 0 {
+			this.cStruct.beam_search.beam_width = 10
+			this.cStruct.beam_search.n_best = 5
+		}
+
+	default:
+		return fmt.Errorf("SetStrategy: unknown sampling strategy %d", strategy)
+	}
+
+	this.cStruct.strategy = strategy
+	return nil
+}
+
+// Offset is the start of the audio to transcribe
+func (this *FullParams) Offset() time.Duration {
+	if this.check() != nil {
+		return 0
+	}
+	return time.Duration(this.cStruct.offset_ms) * time.Millisecond
+}
+
+func (this *FullParams) SetOffset(offset time.Duration) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+
+	ms, err := durationToMs(offset)
+	if err != nil {
+		return fmt.Errorf("SetOffset: %w", err)
+	}
+
+	this.cStruct.offset_ms = ms
+	return nil
+}
+
+// Duration is the length of the audio to transcribe from Offset, 0 to transcribe until the end
+func (this *FullParams) Duration() time.Duration {
+	if this.check() != nil {
+		return 0
+	}
+	return time.Duration(this.cStruct.duration_ms) * time.Millisecond
+}
+
+func (this *FullParams) SetDuration(duration time.Duration) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+
+	ms, err := durationToMs(duration)
+	if err != nil {
+		return fmt.Errorf("SetDuration: %w", err)
+	}
+
+	this.cStruct.duration_ms = ms
+	return nil
+}
+
+func (this *FullParams) Language() eLanguage {
+	if this.check() != nil {
+		return Auto
+	}
+	return this.cStruct.Language
+}
+
+func (this *FullParams) SetLanguage(lang eLanguage) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if !lang.isValid() {
+		return fmt.Errorf("SetLanguage: 0x%X is not a language", int32(lang))
+	}
+
+	this.cStruct.Language = lang
+	return nil
+}
+
+// TholdPt is the timestamp token probability threshold
+func (this *FullParams) TholdPt() float32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.thold_pt
+}
+
+func (this *FullParams) SetTholdPt(val float32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if err := checkProbability(val); err != nil {
+		return fmt.Errorf("SetTholdPt: %w", err)
+	}
+
+	this.cStruct.thold_pt = val
+	return nil
+}
+
+// TholdPtsum is the timestamp token sum probability threshold
+func (this *FullParams) TholdPtsum() float32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.thold_ptsum
+}
+
+func (this *FullParams) SetTholdPtsum(val float32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if err := checkProbability(val); err != nil {
+		return fmt.Errorf("SetTholdPtsum: %w", err)
+	}
+
+	this.cStruct.thold_ptsum = val
+	return nil
+}
+
+// MaxLen is the maximum segment length in characters, 0 for no limit
+func (this *FullParams) MaxLen() int32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.max_len
+}
+
+func (this *FullParams) SetMaxLen(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 {
+		return fmt.Errorf("SetMaxLen: %d is negative", val)
+	}
+
+	this.cStruct.max_len = val
+	return nil
+}
+
+// MaxTokens is the maximum number of tokens per segment, 0 for no limit
+func (this *FullParams) MaxTokens() int32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.max_tokens
+}
+
+func (this *FullParams) SetMaxTokens(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 {
+		return fmt.Errorf("SetMaxTokens: %d is negative", val)
+	}
+
+	this.cStruct.max_tokens = val
+	return nil
+}
+
+// NPast returns n_past of the current strategy
+func (this *FullParams) NPast() int32 {
+	if this.check() != nil {
+		return 0
+	}
+	if this.cStruct.strategy == SsBeamSearch {
+		return this.cStruct.beam_search.n_past
+	}
+	return this.cStruct.greedy.n_past
+}
+
+// SetNPast sets n_past of the current strategy
+func (this *FullParams) SetNPast(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 {
+		return fmt.Errorf("SetNPast: %d is negative", val)
+	}
+
+	if this.cStruct.strategy == SsBeamSearch {
+		this.cStruct.beam_search.n_past = val
+	} else {
+		this.cStruct.greedy.n_past = val
+	}
+	return nil
+}
+
+// BeamSearch returns the beam width and the number of best candidates, -1 unless the strategy is SsBeamSearch
+func (this *FullParams) BeamSearch() (beamWidth int32, nBest int32) {
+	if this.check() != nil {
+		return -1, -1
+	}
+	return this.cStruct.beam_search.beam_width, this.cStruct.beam_search.n_best
+}
+
+// SetBeamSearch requires the SsBeamSearch strategy, see SetStrategy
+func (this *FullParams) SetBeamSearch(beamWidth int32, nBest int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if this.cStruct.strategy != SsBeamSearch {
+		return errors.New("SetBeamSearch: the sampling strategy is not SsBeamSearch")
+	}
+	if beamWidth < 1 {
+		return fmt.Errorf("SetBeamSearch: beam width %d is less than 1", beamWidth)
+	}
+	if nBest < 1 || nBest > beamWidth {
+		return fmt.Errorf("SetBeamSearch: n_best %d is outside [1, %d]", nBest, beamWidth)
+	}
+
+	this.cStruct.beam_search.beam_width = beamWidth
+	this.cStruct.beam_search.n_best = nBest
+	return nil
+}
+
+// AudioCtx overrides the audio context size, 0 to use the model default
+func (this *FullParams) AudioCtx() int32 {
+	if this.check() != nil {
+		return 0
+	}
+	return this.cStruct.audio_ctx
+}
+
+func (this *FullParams) SetAudioCtx(val int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if val < 0 || val > maxAudioCtx {
+		return fmt.Errorf("SetAudioCtx: %d is outside [0, %d]", val, maxAudioCtx)
+	}
+
+	this.cStruct.audio_ctx = val
+	return nil
+}
+
+// Prompt returns a copy of the prompt tokens
+func (this *FullParams) Prompt() []int32 {
+	if this.check() != nil {
+		return nil
+	}
+	return append([]int32(nil), this.prompt...)
+}
+
+// SetPrompt tokenizes the text with the model of the context which created the params, and uses it as the
+// initial prompt, e.g. to bias decoding towards product or speaker names. An empty text clears the prompt.
+func (this *FullParams) SetPrompt(text string) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+	if text == "" {
+		return this.SetPromptTokens(nil)
+	}
+	if this.context == nil {
+		return errors.New("SetPrompt: the params were not created by IContext.FullDefaultParams, use SetPromptTokens")
+	}
+
+	model, err := this.context.GetModel()
+	if err != nil {
+		return fmt.Errorf("SetPrompt: %w", err)
+	}
+	defer model.release()
+
+	tokens, err := model.tokenize(text)
+	if err != nil {
+		return fmt.Errorf("SetPrompt: %w", err)
+	}
+
+	return this.SetPromptTokens(tokens)
+}
+
+// SetPromptTokens uses a copy of the tokens as the initial prompt, nil clears the prompt.
+// The prompt can't be longer than half the text context of the model, or MaxTextCTX if that is less.
+func (this *FullParams) SetPromptTokens(tokens []int32) error {
+	if err := this.check(); err != nil {
+		return err
+	}
+
+	limit := int32(maxPromptTokens)
+	if this.cStruct.n_max_text_ctx < limit {
+		limit = this.cStruct.n_max_text_ctx
+	}
+	if int64(len(tokens)) > int64(limit) {
+		return fmt.Errorf("SetPromptTokens: %d tokens is longer than the limit of %d", len(tokens), limit)
+	}
+
+	var native uintptr
+	if len(tokens) > 0 {
+		var err error
+		if native, err = allocTokens(tokens); err != nil {
+			return fmt.Errorf("SetPromptTokens: %w", err)
+		}
+	}
+
+	this.freePrompt()
+	if native == 0 {
+		return nil
+	}
+
+	// The native code keeps the address, so it can't point into the Go heap
+	this.prompt = append(make([]int32, 0, len(tokens)), tokens...)
+	this.promptTokens = native
+	this.cStruct.prompt_tokens = native
+	this.cStruct.prompt_n_tokens = int32(len(tokens))
+	runtime.SetFinalizer(this, (*FullParams).freePrompt)
+	return nil
+}
+
+// freePrompt clears the prompt and frees its native copy
+func (this *FullParams) freePrompt() {
+	if this.promptTokens != 0 {
+		freeTokens(this.promptTokens)
+		runtime.SetFinalizer(this, nil)
+	}
+	this.prompt = nil
+	this.promptTokens = 0
+	this.cStruct.prompt_tokens = 0
+	this.cStruct.prompt_n_tokens = 0
+}
+
+// whisper.dll keeps at most n_text_ctx / 2 tokens of prompt, like whisper_full of whisper.cpp, and drops the older ones.
+// n_text_ctx is 448 in the hyperparameters of every Whisper model, from tiny to large, and iModel doesn't expose it.
+const maxPromptTokens = 448 / 2
+
+// n_audio_ctx of every published model, 30 seconds of audio
+const maxAudioCtx = 1500
+
+var errParamsNil = errors.New("FullParams is not initialised, use IContext.FullDefaultParams")
+
+func (this *FullParams) check() error {
+	if this == nil || this.cStruct == nil {
+		return errParamsNil
+	}
+	return nil
+}
+
+func checkProbability(val float32) error {
+	if !(val >= 0 && val <= 1) {
+		return fmt.Errorf("%v is outside [0, 1]", val)
+	}
+	return nil
+}
+
+// durationToMs converts to the int32 milliseconds of sFullParams
+func durationToMs(d time.Duration) (int32, error) {
+	if d < 0 {
+		return 0, fmt.Errorf("%v is negative", d)
+	}
+
+	ms := d.Milliseconds()
+	if ms > math.MaxInt32 {
+		return 0, fmt.Errorf("%v is too long", d)
+	}
+	return int32(ms), nil
 }
 
 /*using pfnNewSegment = HRESULT( __cdecl* )( iContext* ctx, uint32_t n_new, void* user_data ) noexcept;*/
@@ -95,7 +518,7 @@ func (this *FullParams) SetNewSegmentCallback(cb NewSegmentCallback_Type) {
 	} else if this.cStruct == nil {
 		return
 	}
-	this.cStruct.new_segment_callback = syscall.NewCallback(cb)
+	this.cStruct.new_segment_callback = newCallback(cb)
 }
 
 /*
@@ -110,7 +533,7 @@ func (this *FullParams) SetEncoderBeginCallback(cb EncoderBeginCallback_Type) {
 		return
 	}
 
-	this.cStruct.encoder_begin_callback = syscall.NewCallback(cb)
+	this.cStruct.encoder_begin_callback = newCallback(cb)
 }
 
 func (this *FullParams) TestDefaultsOK() bool {
@@ -140,7 +563,7 @@ func (this *FullParams) TestDefaultsOK() bool {
 		return false
 	}
 
-	// Todo ... why do these not line up as expected.. is our struct out of alignment ?
+	// The beam search defaults are not checked, the struct layout itself is checked by CheckLayouts
 	/*
 		if this.cStruct.strategy == ssGreedy {
 			if this.cStruct.beam_search.n_past != -1 ||
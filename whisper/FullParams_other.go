@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func allocTokens(tokens []int32) (uintptr, error) {
+	return 0, errUnsupported("allocTokens")
+}
+
+func freeTokens(addr uintptr) {
+}
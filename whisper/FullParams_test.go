@@ -0,0 +1,202 @@
+package whisper
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// testFullParams returns the params IContext.FullDefaultParams returns for the greedy strategy
+func testFullParams() *FullParams {
+	cs := _newFullParams_cStruct()
+	cs.strategy = SsGreedy
+	cs.n_max_text_ctx = 16384
+	cs.Flags = FlagPrintProgress | FlagPrintTimestamps
+	cs.thold_pt = 0.01
+	cs.thold_ptsum = 0.01
+	cs.Language = English
+	cs.beam_search.n_past = -1
+	cs.beam_search.beam_width = -1
+	cs.beam_search.n_best = -1
+	return NewFullParams(cs)
+}
+
+func TestFullParamsDefaults(t *testing.T) {
+	if !testFullParams().TestDefaultsOK() {
+		t.Fatal("the defaults are not OK")
+	}
+}
+
+func TestFullParamsSetters(t *testing.T) {
+	params := testFullParams()
+	expectError := func(name string, err error) {
+		t.Helper()
+		if err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+	expectOK := func(name string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	expectOK("4 threads", params.SetCpuThreads(4))
+	expectError("-1 threads", params.SetCpuThreads(-1))
+	if params.CpuThreads() != 4 {
+		t.Errorf("CpuThreads() = %d", params.CpuThreads())
+	}
+
+	expectOK("n_max_text_ctx 0", params.SetMaxTextCTX(0))
+	expectError("n_max_text_ctx -1", params.SetMaxTextCTX(-1))
+
+	expectOK("offset", params.SetOffset(1500*time.Millisecond))
+	expectError("negative offset", params.SetOffset(-time.Millisecond))
+	expectError("offset past int32 milliseconds", params.SetOffset((math.MaxInt32+1)*time.Millisecond))
+	if params.Offset() != 1500*time.Millisecond {
+		t.Errorf("Offset() = %v", params.Offset())
+	}
+
+	expectOK("duration", params.SetDuration(time.Minute))
+	expectError("negative duration", params.SetDuration(-time.Second))
+	if params.Duration() != time.Minute {
+		t.Errorf("Duration() = %v", params.Duration())
+	}
+
+	expectOK("German", params.SetLanguage(German))
+	expectOK("Auto", params.SetLanguage(Auto))
+	expectError("language 0x1", params.SetLanguage(eLanguage(1)))
+	if params.Language() != Auto {
+		t.Errorf("Language() = %v", params.Language())
+	}
+
+	for _, val := range []float32{0, 0.5, 1} {
+		expectOK("thold_pt", params.SetTholdPt(val))
+		expectOK("thold_ptsum", params.SetTholdPtsum(val))
+	}
+	for _, val := range []float32{-0.1, 1.5, float32(math.NaN())} {
+		expectError("thold_pt", params.SetTholdPt(val))
+		expectError("thold_ptsum", params.SetTholdPtsum(val))
+	}
+	if params.TholdPt() != 1 || params.TholdPtsum() != 1 {
+		t.Errorf("thresholds %v %v", params.TholdPt(), params.TholdPtsum())
+	}
+
+	expectOK("max_len", params.SetMaxLen(42))
+	expectError("negative max_len", params.SetMaxLen(-1))
+	expectOK("max_tokens", params.SetMaxTokens(0))
+	expectError("negative max_tokens", params.SetMaxTokens(-1))
+	if params.MaxLen() != 42 || params.MaxTokens() != 0 {
+		t.Errorf("max_len %d, max_tokens %d", params.MaxLen(), params.MaxTokens())
+	}
+
+	expectOK("audio_ctx", params.SetAudioCtx(maxAudioCtx))
+	expectError("audio_ctx past the model", params.SetAudioCtx(maxAudioCtx+1))
+	expectError("negative audio_ctx", params.SetAudioCtx(-1))
+	if params.AudioCtx() != maxAudioCtx {
+		t.Errorf("AudioCtx() = %d", params.AudioCtx())
+	}
+}
+
+func TestFullParamsStrategy(t *testing.T) {
+	params := testFullParams()
+
+	if err := params.SetBeamSearch(5, 5); err == nil {
+		t.Fatal("beam search settings were accepted with the greedy strategy")
+	}
+	if err := params.SetNPast(3); err != nil || params.NPast() != 3 {
+		t.Fatalf("NPast() = %d, %v", params.NPast(), err)
+	}
+
+	if err := params.SetStrategy(SsBeamSearch); err != nil {
+		t.Fatal(err)
+	}
+	if width, best := params.BeamSearch(); width != 10 || best != 5 {
+		t.Fatalf("default beam search %d, %d", width, best)
+	}
+	if params.cStruct.greedy.n_past != -1 || params.NPast() != 0 {
+		t.Fatalf("n_past greedy %d, beam search %d", params.cStruct.greedy.n_past, params.NPast())
+	}
+
+	if err := params.SetBeamSearch(3, 4); err == nil {
+		t.Fatal("n_best larger than the beam width was accepted")
+	}
+	if err := params.SetBeamSearch(0, 0); err == nil {
+		t.Fatal("a beam width of 0 was accepted")
+	}
+	if err := params.SetBeamSearch(4, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := params.SetStrategy(SsGreedy); err != nil {
+		t.Fatal(err)
+	}
+	if width, best := params.BeamSearch(); width != -1 || best != -1 || params.Strategy() != SsGreedy {
+		t.Fatalf("greedy with beam search %d, %d", width, best)
+	}
+	if err := params.SetStrategy(SsINVALIDARG); err == nil || params.Strategy() != SsGreedy {
+		t.Fatal("an unknown strategy was accepted")
+	}
+}
+
+func TestRemoveFlags(t *testing.T) {
+	params := testFullParams()
+
+	// Removing flags which are not set must not set them, as the XOR it replaced did
+	params.RemoveFlags(FlagPrintProgress | FlagTranslate)
+	if params.Flags() != FlagPrintTimestamps {
+		t.Fatalf("flags 0x%X", params.Flags())
+	}
+
+	params.AddFlags(FlagTranslate | FlagNoContext)
+	if !params.HasFlags(FlagTranslate|FlagNoContext) || params.HasFlags(FlagTranslate|FlagPrintProgress) {
+		t.Fatalf("flags 0x%X", params.Flags())
+	}
+
+	params.RemoveFlags(FlagTranslate)
+	params.RemoveFlags(FlagTranslate)
+	if params.Flags() != FlagPrintTimestamps|FlagNoContext {
+		t.Fatalf("flags 0x%X", params.Flags())
+	}
+}
+
+func TestFullParamsUninitialised(t *testing.T) {
+	for _, params := range []*FullParams{nil, {}} {
+		if err := params.SetMaxLen(1); !errors.Is(err, errParamsNil) {
+			t.Errorf("SetMaxLen returned %v", err)
+		}
+		if err := params.SetStrategy(SsGreedy); !errors.Is(err, errParamsNil) {
+			t.Errorf("SetStrategy returned %v", err)
+		}
+		if params.Strategy() != SsINVALIDARG || params.Flags() != FlagNone || params.Language() != Auto {
+			t.Errorf("%v %v %v", params.Strategy(), params.Flags(), params.Language())
+		}
+		if width, best := params.BeamSearch(); width != -1 || best != -1 {
+			t.Errorf("beam search %d, %d", width, best)
+		}
+		params.AddFlags(FlagTranslate)
+		params.RemoveFlags(FlagTranslate)
+	}
+}
+
+func TestSetPromptTokensLimit(t *testing.T) {
+	params := testFullParams()
+
+	if err := params.SetPromptTokens(make([]int32, maxPromptTokens+1)); err == nil {
+		t.Fatalf("%d tokens were accepted", maxPromptTokens+1)
+	}
+
+	params.SetMaxTextCTX(10)
+	if err := params.SetPromptTokens(make([]int32, 11)); err == nil {
+		t.Fatal("a prompt longer than n_max_text_ctx was accepted")
+	}
+
+	if err := params.SetPromptTokens(nil); err != nil {
+		t.Fatal(err)
+	}
+	if params.Prompt() != nil || params.cStruct.prompt_tokens != 0 || params.cStruct.prompt_n_tokens != 0 {
+		t.Fatal("clearing the prompt left tokens")
+	}
+}
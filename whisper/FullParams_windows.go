@@ -0,0 +1,23 @@
+package whisper
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// allocTokens copies the tokens into LocalAlloc memory, which the Go GC neither moves nor frees
+func allocTokens(tokens []int32) (uintptr, error) {
+	addr, err := windows.LocalAlloc(0, uint32(len(tokens)*4))
+	if err != nil {
+		return 0, err
+	}
+
+	// LocalAlloc memory is outside of the Go heap
+	copy(unsafe.Slice((*int32)(syscallPointer(addr)), len(tokens)), tokens)
+	return addr, nil
+}
+
+func freeTokens(addr uintptr) {
+	windows.LocalFree(windows.Handle(addr))
+}
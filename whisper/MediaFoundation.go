@@ -1,14 +1,5 @@
 package whisper
 
-import (
-	"errors"
-	"fmt"
-	"syscall"
-	"unsafe"
-
-	"golang.org/x/sys/windows"
-)
-
 // https://github.com/Const-me/Whisper/blob/843a2a6ca6ea47c5ac4889a281badfc808d0ea01/Whisper/API/IMediaFoundation.h
 
 type IMediaFoundation struct {
@@ -26,91 +17,6 @@ type IMediaFoundationVtbl struct {
 	openCaptureDevice  uintptr // ( LPCTSTR endpoint, const sCaptureParams& captureParams, iAudioCapture** pp );
 }
 
-func (this *IMediaFoundation) AddRef() int32 {
-	ret, _, _ := syscall.Syscall(
-		this.lpVtbl.AddRef,
-		1,
-		uintptr(unsafe.Pointer(this)),
-		0,
-		0)
-	return int32(ret)
-}
-
-func (this *IMediaFoundation) Release() int32 {
-	ret, _, _ := syscall.Syscall(
-		this.lpVtbl.Release,
-		1,
-		uintptr(unsafe.Pointer(this)),
-		0,
-		0)
-	return int32(ret)
-}
-
-// ( LPCTSTR path, bool stereo, iAudioBuffer** pp ) const;
-func (this *IMediaFoundation) LoadAudioFile(file string, stereo bool) (*iAudioBuffer, error) {
-
-	var buffer *iAudioBuffer
-
-	UTFFileName, _ := windows.UTF16PtrFromString(file)
-
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.loadAudioFile,
-		uintptr(unsafe.Pointer(this)),
-		uintptr(unsafe.Pointer(UTFFileName)),
-		uintptr(1), // Todo ... Stereo !
-		uintptr(unsafe.Pointer(&buffer)))
-
-	if windows.Handle(ret) != windows.S_OK {
-		fmt.Printf("loadAudioFile failed: %s\n", syscall.Errno(ret).Error())
-		return nil, syscall.Errno(ret)
-	}
-
-	return buffer, nil
-}
-
-func (this *IMediaFoundation) OpenAudioFile(file string, stereo bool) (*iAudioReader, error) {
-
-	var buffer *iAudioReader
-
-	UTFFileName, _ := windows.UTF16PtrFromString(file)
-
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.openAudioFile,
-		uintptr(unsafe.Pointer(this)),
-		uintptr(unsafe.Pointer(UTFFileName)),
-		uintptr(1), // Todo ... Stereo !
-		uintptr(unsafe.Pointer(&buffer)))
-
-	if windows.Handle(ret) != windows.S_OK {
-		fmt.Printf("openAudioFile failed: %s\n", syscall.Errno(ret).Error())
-		return nil, syscall.Errno(ret)
-	}
-
-	return buffer, nil
-}
-
-func (this *IMediaFoundation) LoadAudioFileData(inbuffer *[]byte, stereo bool) (*iAudioReader, error) {
-
-	var reader *iAudioReader
-
-	// loadAudioFileData( const void* data, uint64_t size, bool stereo, iAudioReader** pp );
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.loadAudioFileData,
-		uintptr(unsafe.Pointer(this)),
-
-		uintptr(unsafe.Pointer(&(*inbuffer)[0])),
-		uintptr(uint64(len(*inbuffer))),
-		uintptr(1), // Todo ... Stereo !
-		uintptr(unsafe.Pointer(&reader)))
-
-	if windows.Handle(ret) != windows.S_OK {
-		fmt.Printf("LoadAudioFileData failed: %s\n", syscall.Errno(ret).Error())
-		return nil, syscall.Errno(ret)
-	}
-
-	return reader, nil
-}
-
 // ************************************************************
 
 type iAudioBuffer struct {
@@ -127,34 +33,26 @@ type iAudioBufferVtbl struct {
 	getTime        uintptr // ( int64_t& rdi )
 }
 
-func (this *iAudioBuffer) AddRef() int32 {
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.AddRef,
-		uintptr(unsafe.Pointer(this)),
-	)
-	return int32(ret)
+// AsAudioBuffer returns the iAudioBuffer held by an interface value, e.g. an engine.AudioBuffer,
+// and false when the value is something else
+func AsAudioBuffer(value any) (*iAudioBuffer, bool) {
+	buffer, ok := value.(*iAudioBuffer)
+	return buffer, ok && buffer != nil
 }
 
-func (this *iAudioBuffer) Release() int32 {
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.Release,
-		uintptr(unsafe.Pointer(this)),
-	)
-	return int32(ret)
-}
-
-func (this *iAudioBuffer) CountSamples() (uint32, error) {
-
-	ret, _, err := syscall.SyscallN(
-		this.lpVtbl.countSamples,
-		uintptr(unsafe.Pointer(this)),
-	)
+// Pcm returns a copy of the mono samples, and the stereo ones when available
+func (this *iAudioBuffer) Pcm() (*PcmBuffer, error) {
+	mono, err := this.PcmMono()
+	if err != nil {
+		return nil, err
+	}
 
-	if err != 0 {
-		return 0, errors.New(err.Error())
+	stereo, err := this.PcmStereo()
+	if err != nil {
+		return nil, err
 	}
 
-	return uint32(ret), nil
+	return &PcmBuffer{Mono: mono, Stereo: stereo}, nil
 }
 
 // ************************************************************
@@ -173,40 +71,6 @@ type iAudioReaderVtbl struct {
 	requestedStereo uintptr // ()
 }
 
-func (this *iAudioReader) AddRef() int32 {
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.AddRef,
-		uintptr(unsafe.Pointer(this)),
-	)
-	return int32(ret)
-}
-
-func (this *iAudioReader) Release() int32 {
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.Release,
-		uintptr(unsafe.Pointer(this)),
-	)
-	return int32(ret)
-}
-
-func (this *iAudioReader) GetDuration() (uint64, error) {
-
-	var rdi int64
-
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.getDuration,
-		uintptr(unsafe.Pointer(this)),
-		uintptr(unsafe.Pointer(&rdi)),
-	)
-
-	if windows.Handle(ret) != windows.S_OK {
-		fmt.Printf("LoadAudioFileData failed: %s\n", syscall.Errno(ret).Error())
-		return 0, syscall.Errno(ret)
-	}
-
-	return uint64(rdi), nil
-}
-
 // ************************************************************
 
 type iAudioCapture struct {
@@ -220,3 +84,12 @@ type iAudioCaptureVtbl struct {
 	getReader      uintptr // ( IMFSourceReader** pp )
 	getParams      uintptr // returns sCaptureParams&
 }
+
+// sCaptureParams - iMediaFoundation.h
+type sCaptureParams struct {
+	minDuration      float32
+	maxDuration      float32
+	dropStartSilence float32
+	pauseDuration    float32
+	flags            uint32 // eCaptureFlags
+}
@@ -0,0 +1,68 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+import (
+	"time"
+)
+
+func (this *IMediaFoundation) AddRef() int32 {
+	return 0
+}
+
+func (this *IMediaFoundation) Release() int32 {
+	return 0
+}
+
+func (this *IMediaFoundation) LoadAudioFile(file string, stereo bool) (*iAudioBuffer, error) {
+	return nil, errUnsupported("LoadAudioFile")
+}
+
+func (this *IMediaFoundation) OpenAudioFile(file string, stereo bool) (*iAudioReader, error) {
+	return nil, errUnsupported("OpenAudioFile")
+}
+
+func (this *IMediaFoundation) LoadAudioFileData(inbuffer *[]byte, stereo bool) (*iAudioReader, error) {
+	return nil, errUnsupported("LoadAudioFileData")
+}
+
+func (this *iAudioBuffer) AddRef() int32 {
+	return 0
+}
+
+func (this *iAudioBuffer) Release() int32 {
+	return 0
+}
+
+func (this *iAudioBuffer) CountSamples() (uint32, error) {
+	return 0, errUnsupported("iAudioBuffer.CountSamples")
+}
+
+func (this *iAudioBuffer) PcmMono() ([]float32, error) {
+	return nil, errUnsupported("iAudioBuffer.PcmMono")
+}
+
+func (this *iAudioBuffer) PcmStereo() ([]float32, error) {
+	return nil, errUnsupported("iAudioBuffer.PcmStereo")
+}
+
+func (this *iAudioBuffer) GetTime() (time.Duration, error) {
+	return 0, errUnsupported("iAudioBuffer.GetTime")
+}
+
+func (this *iAudioReader) AddRef() int32 {
+	return 0
+}
+
+func (this *iAudioReader) Release() int32 {
+	return 0
+}
+
+func (this *iAudioReader) GetDuration() (uint64, error) {
+	return 0, errUnsupported("iAudioReader.GetDuration")
+}
+
+func (this *iAudioReader) RequestedStereo() bool {
+	return false
+}
@@ -0,0 +1,246 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func (this *IMediaFoundation) AddRef() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.AddRef,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *IMediaFoundation) Release() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.Release,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+// ( LPCTSTR path, bool stereo, iAudioBuffer** pp ) const;
+func (this *IMediaFoundation) LoadAudioFile(file string, stereo bool) (*iAudioBuffer, error) {
+
+	var buffer *iAudioBuffer
+
+	UTFFileName, _ := windows.UTF16PtrFromString(file)
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.loadAudioFile,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(UTFFileName)),
+		boolArg(stereo),
+		uintptr(unsafe.Pointer(&buffer)))
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("loadAudioFile failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	return buffer, nil
+}
+
+func (this *IMediaFoundation) OpenAudioFile(file string, stereo bool) (*iAudioReader, error) {
+
+	var buffer *iAudioReader
+
+	UTFFileName, _ := windows.UTF16PtrFromString(file)
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.openAudioFile,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(UTFFileName)),
+		boolArg(stereo),
+		uintptr(unsafe.Pointer(&buffer)))
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("openAudioFile failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	return buffer, nil
+}
+
+func (this *IMediaFoundation) LoadAudioFileData(inbuffer *[]byte, stereo bool) (*iAudioReader, error) {
+
+	if inbuffer == nil || len(*inbuffer) == 0 {
+		return nil, errors.New("LoadAudioFileData: the buffer is empty")
+	}
+
+	var reader *iAudioReader
+
+	// loadAudioFileData( const void* data, uint64_t size, bool stereo, iAudioReader** pp );
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.loadAudioFileData,
+		uintptr(unsafe.Pointer(this)),
+
+		uintptr(unsafe.Pointer(&(*inbuffer)[0])),
+		uintptr(uint64(len(*inbuffer))),
+		boolArg(stereo),
+		uintptr(unsafe.Pointer(&reader)))
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("LoadAudioFileData failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	return reader, nil
+}
+
+// boolArg passes a C++ bool argument, which only uses the low byte of the register
+func boolArg(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (this *iAudioBuffer) AddRef() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.AddRef,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+func (this *iAudioBuffer) Release() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.Release,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+// CountSamples returns the number of 16 kHz mono samples.
+// The method can't fail; the error is kept for compatibility, it used to report a stale GetLastError value.
+func (this *iAudioBuffer) CountSamples() (uint32, error) {
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.countSamples,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	return uint32(ret), nil
+}
+
+// PcmMono returns a copy of the 16 kHz mono samples
+func (this *iAudioBuffer) PcmMono() ([]float32, error) {
+	count, err := this.CountSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getPcmMono,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	return copyPcm(ret, int(count))
+}
+
+// PcmStereo returns a copy of the interleaved 16 kHz stereo samples, 2 per sample of PcmMono.
+// Returns nil when the audio was not loaded with stereo = true.
+func (this *iAudioBuffer) PcmStereo() ([]float32, error) {
+	count, err := this.CountSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getPcmStereo,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	if ret == 0 {
+		return nil, nil
+	}
+	return copyPcm(ret, int(count)*2)
+}
+
+// GetTime returns the start time of the buffer, in the stream it was captured or decoded from
+func (this *iAudioBuffer) GetTime() (time.Duration, error) {
+
+	var rdi int64
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getTime,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(&rdi)),
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		return 0, syscall.Errno(ret)
+	}
+
+	return sTimeSpan{Ticks: uint64(rdi)}.Duration(), nil
+}
+
+// copyPcm copies count floats from native memory, the buffer owning them may be released afterwards
+func copyPcm(ret uintptr, count int) ([]float32, error) {
+	if count == 0 {
+		return []float32{}, nil
+	}
+	if ret == 0 {
+		return nil, errors.New("iAudioBuffer returned no samples")
+	}
+
+	pcm := make([]float32, count)
+	copy(pcm, unsafe.Slice((*float32)(syscallPointer(ret)), count))
+	return pcm, nil
+}
+
+func (this *iAudioReader) AddRef() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.AddRef,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+func (this *iAudioReader) Release() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.Release,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
+
+func (this *iAudioReader) GetDuration() (uint64, error) {
+
+	var rdi int64
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getDuration,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(&rdi)),
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("getDuration failed: %s\n", syscall.Errno(ret).Error())
+		return 0, syscall.Errno(ret)
+	}
+
+	return uint64(rdi), nil
+}
+
+// RequestedStereo is true when the reader was opened with stereo = true
+func (this *iAudioReader) RequestedStereo() bool {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.requestedStereo,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	return windows.Handle(ret) == windows.S_OK
+}
@@ -2,8 +2,6 @@ package whisper
 
 import (
 	"unsafe"
-
-	"golang.org/x/sys/windows"
 )
 
 // Re-implemented sModelSetup.h
@@ -75,7 +73,7 @@ func ModelSetup(flags eGpuModelFlags, GPU string) *sModelSetup {
 }
 
 func (this *sModelSetup) isFlagSet(flag eGpuModelFlags) bool {
-	return (this.flags & flag) == 0
+	return (this.flags & flag) != 0
 }
 
 func (this *sModelSetup) AsCType() *_sModelSetup {
@@ -89,7 +87,7 @@ func (this *sModelSetup) AsCType() *_sModelSetup {
 	// Conver Go String to wchar_t, AKA UTF-16
 	if this.adapter != "" {
 		var UTF16str *uint16
-		UTF16str, err = windows.UTF16PtrFromString(this.adapter)
+		UTF16str, err = utf16PtrFromString(this.adapter)
 		ctype.adapter = uintptr(unsafe.Pointer(UTF16str))
 	}
 
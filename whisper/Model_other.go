@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func (this *Model) AddRef() int32 {
+	return 0
+}
+
+func (this *Model) Release() int32 {
+	return 0
+}
+
+func (this *Model) CreateContext() (*IContext, error) {
+	return nil, errUnsupported("createContext")
+}
+
+func (this *Model) IsMultilingual() bool {
+	return false
+}
+
+func (this *Model) Clone() (*_IModel, error) {
+	return nil, errUnsupported("Model.Clone")
+}
+
+func (this *_IModel) tokenize(text string) ([]int32, error) {
+	return nil, errUnsupported("Model.Tokenize")
+}
+
+func (this *_IModel) release() int32 {
+	return 0
+}
@@ -0,0 +1,137 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func (this *Model) AddRef() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.cStruct.lpVtbl.AddRef,
+		1,
+		uintptr(unsafe.Pointer(this.cStruct)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *Model) Release() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.cStruct.lpVtbl.Release,
+		1,
+		uintptr(unsafe.Pointer(this.cStruct)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *Model) CreateContext() (*IContext, error) {
+	var context *IContext
+
+	/*
+		ret, _, err := syscall.Syscall(
+			this.cStruct.lpVtbl.createContext,
+			2, // Why was this 1, rather than 2 ?? 1 seemed to work fine
+			uintptr(unsafe.Pointer(this.cStruct)),
+			uintptr(unsafe.Pointer(&context)),
+			0)*/
+	ret, _, err := syscall.SyscallN(
+		this.cStruct.lpVtbl.createContext,
+		uintptr(unsafe.Pointer(this.cStruct)),
+		uintptr(unsafe.Pointer(&context)))
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("createContext failed: %s\n", err.Error())
+	}
+
+	if windows.Handle(ret) != windows.S_OK {
+		return nil, fmt.Errorf("loadModel failed: %w", err)
+	}
+
+	return context, nil
+}
+
+func (this *Model) IsMultilingual() bool {
+	ret, _, _ := syscall.SyscallN(
+		this.cStruct.lpVtbl.isMultilingual,
+		uintptr(unsafe.Pointer(this.cStruct)),
+	)
+
+	return bool(windows.Handle(ret) == windows.S_OK)
+}
+
+func (this *Model) Clone() (*_IModel, error) {
+
+	if err := requireFeature("Clone", "cloning models", func(caps Capabilities) bool { return caps.CloneableModels }); err != nil {
+		return nil, err
+	}
+
+	if !this.setup.isFlagSet(gmf_Cloneable) {
+		return nil, errors.New("Model is not cloneable")
+	}
+
+	var modelptr *_IModel
+
+	ret, _, _ := syscall.SyscallN(
+		this.cStruct.lpVtbl.clone,
+		uintptr(unsafe.Pointer(this.cStruct)),
+		uintptr(unsafe.Pointer(&modelptr)),
+	)
+
+	if windows.Handle(ret) == windows.S_OK {
+		return modelptr, nil
+	} else {
+		return nil, errors.New("Model.Clone() failed : " + syscall.Errno(ret).Error())
+	}
+}
+
+/*
+using pfnDecodedTokens = void( __cdecl* )( const int* arr, int length, void* pv ) noexcept;
+*/
+var tokenizeCallback = syscall.NewCallbackCDecl(func(arr *int32, length uintptr, pv uintptr) uintptr {
+	tokens, ok := handles.get(pv).(*[]int32)
+	if !ok || arr == nil || int32(length) <= 0 {
+		return 0
+	}
+
+	*tokens = append(*tokens, unsafe.Slice(arr, int32(length))...)
+	return 0
+})
+
+func (this *_IModel) tokenize(text string) ([]int32, error) {
+	ctext, err := syscall.BytePtrFromString(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []int32{}
+	handle := handles.add(&tokens)
+	defer handles.remove(handle)
+
+	// tokenize( const char* text, pfnDecodedTokens pfn, void* pv );
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.tokenize,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(ctext)),
+		tokenizeCallback,
+		handle,
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		return nil, errors.New("Model.Tokenize() failed : " + syscall.Errno(ret).Error())
+	}
+
+	return tokens, nil
+}
+
+func (this *_IModel) release() int32 {
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.Release,
+		uintptr(unsafe.Pointer(this)),
+	)
+	return int32(ret)
+}
@@ -0,0 +1,451 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Params describes changes to the FullParams returned by IContext.FullDefaultParams.
+// It is plain Go, so it can be built, validated and logged without the DLL, see NewParams and ParamsPreset.
+// Settings which were not given keep the defaults of the DLL.
+type Params struct {
+	strategy eSamplingStrategy
+	preset   string
+
+	addFlags    eFullParamsFlags
+	removeFlags eFullParamsFlags
+
+	cpuThreads *int32
+	maxTextCTX *int32
+	offset     *time.Duration
+	duration   *time.Duration
+	language   *eLanguage
+	tholdPt    *float32
+	tholdPtsum *float32
+	maxLen     *int32
+	maxTokens  *int32
+	beamWidth  *int32
+	nBest      *int32
+	audioCtx   *int32
+}
+
+type ParamsOption func(*Params) error
+
+// NewParams validates and applies the options in order
+func NewParams(strategy eSamplingStrategy, opts ...ParamsOption) (*Params, error) {
+	if strategy != SsGreedy && strategy != SsBeamSearch {
+		return nil, fmt.Errorf("NewParams: unknown sampling strategy %d", strategy)
+	}
+
+	this := &Params{strategy: strategy}
+	if err := this.apply(opts); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+func (this *Params) apply(opts []ParamsOption) error {
+	for _, opt := range opts {
+		if err := opt(this); err != nil {
+			return err
+		}
+	}
+
+	if this.beamWidth != nil && this.strategy != SsBeamSearch {
+		return errors.New("WithBeam requires the SsBeamSearch strategy")
+	}
+	return nil
+}
+
+// WithStrategy replaces the sampling strategy, switching to SsGreedy drops the beam search settings
+func WithStrategy(strategy eSamplingStrategy) ParamsOption {
+	return func(this *Params) error {
+		if strategy != SsGreedy && strategy != SsBeamSearch {
+			return fmt.Errorf("WithStrategy: unknown sampling strategy %d", strategy)
+		}
+		if strategy != SsBeamSearch {
+			this.beamWidth = nil
+			this.nBest = nil
+		}
+		this.strategy = strategy
+		return nil
+	}
+}
+
+func WithFlags(flags eFullParamsFlags) ParamsOption {
+	return func(this *Params) error {
+		this.addFlags |= flags
+		this.removeFlags &^= flags
+		return nil
+	}
+}
+
+func WithoutFlags(flags eFullParamsFlags) ParamsOption {
+	return func(this *Params) error {
+		this.removeFlags |= flags
+		this.addFlags &^= flags
+		return nil
+	}
+}
+
+// WithTranslate translates the transcript to English
+func WithTranslate() ParamsOption {
+	return WithFlags(FlagTranslate)
+}
+
+func WithTokenTimestamps() ParamsOption {
+	return WithFlags(FlagTokenTimestamps)
+}
+
+func WithLanguage(lang eLanguage) ParamsOption {
+	return func(this *Params) error {
+		if !lang.isValid() {
+			return fmt.Errorf("WithLanguage: 0x%X is not a language", int32(lang))
+		}
+		this.language = &lang
+		return nil
+	}
+}
+
+// WithWindow only transcribes the audio from offset, for duration or until the end when duration is 0
+func WithWindow(offset time.Duration, duration time.Duration) ParamsOption {
+	return func(this *Params) error {
+		if _, err := durationToMs(offset); err != nil {
+			return fmt.Errorf("WithWindow: offset %w", err)
+		}
+		if _, err := durationToMs(duration); err != nil {
+			return fmt.Errorf("WithWindow: duration %w", err)
+		}
+		this.offset = &offset
+		this.duration = &duration
+		return nil
+	}
+}
+
+// WithBeam sets the beam width, and the number of best candidates. The strategy must be SsBeamSearch.
+func WithBeam(beamWidth int32, nBest int32) ParamsOption {
+	return func(this *Params) error {
+		if beamWidth < 1 {
+			return fmt.Errorf("WithBeam: beam width %d is less than 1", beamWidth)
+		}
+		if nBest < 1 || nBest > beamWidth {
+			return fmt.Errorf("WithBeam: n_best %d is outside [1, %d]", nBest, beamWidth)
+		}
+		this.beamWidth = &beamWidth
+		this.nBest = &nBest
+		return nil
+	}
+}
+
+func WithCpuThreads(threads int32) ParamsOption {
+	return func(this *Params) error {
+		if threads < 0 {
+			return fmt.Errorf("WithCpuThreads: %d threads is negative", threads)
+		}
+		this.cpuThreads = &threads
+		return nil
+	}
+}
+
+func WithMaxTextCTX(val int32) ParamsOption {
+	return func(this *Params) error {
+		if val < 0 {
+			return fmt.Errorf("WithMaxTextCTX: %d is negative", val)
+		}
+		this.maxTextCTX = &val
+		return nil
+	}
+}
+
+// WithThresholds sets the timestamp token probability, and sum of probabilities, thresholds
+func WithThresholds(tholdPt float32, tholdPtsum float32) ParamsOption {
+	return func(this *Params) error {
+		if err := checkProbability(tholdPt); err != nil {
+			return fmt.Errorf("WithThresholds: thold_pt %w", err)
+		}
+		if err := checkProbability(tholdPtsum); err != nil {
+			return fmt.Errorf("WithThresholds: thold_ptsum %w", err)
+		}
+		this.tholdPt = &tholdPt
+		this.tholdPtsum = &tholdPtsum
+		return nil
+	}
+}
+
+// WithMaxLen limits the segment length in characters, 0 for no limit
+func WithMaxLen(val int32) ParamsOption {
+	return func(this *Params) error {
+		if val < 0 {
+			return fmt.Errorf("WithMaxLen: %d is negative", val)
+		}
+		this.maxLen = &val
+		return nil
+	}
+}
+
+// WithMaxTokens limits the number of tokens per segment, 0 for no limit
+func WithMaxTokens(val int32) ParamsOption {
+	return func(this *Params) error {
+		if val < 0 {
+			return fmt.Errorf("WithMaxTokens: %d is negative", val)
+		}
+		this.maxTokens = &val
+		return nil
+	}
+}
+
+// WithAudioCtx overrides the audio context size, smaller is faster and less accurate. 0 uses the model default
+func WithAudioCtx(val int32) ParamsOption {
+	return func(this *Params) error {
+		if val < 0 || val > maxAudioCtx {
+			return fmt.Errorf("WithAudioCtx: %d is outside [0, %d]", val, maxAudioCtx)
+		}
+		this.audioCtx = &val
+		return nil
+	}
+}
+
+// ************************************************************
+
+// The flags FullDefaultParams sets, which print to the console
+const printFlags = FlagPrintProgress | FlagPrintTimestamps | FlagPrintRealtime | FlagPrintSpecial
+
+type paramsPreset struct {
+	strategy eSamplingStrategy
+	opts     []ParamsOption
+}
+
+var paramsPresets = map[string]paramsPreset{
+	// Segments short enough for a subtitle line, with token timestamps for word timings
+	"subtitles": {SsGreedy, []ParamsOption{
+		WithoutFlags(printFlags),
+		WithTokenTimestamps(),
+		WithMaxLen(42),
+	}},
+
+	// Low latency for short independent clips, e.g. from a capture device
+	"live": {SsGreedy, []ParamsOption{
+		WithoutFlags(printFlags),
+		WithFlags(FlagNoContext | FlagSingleSegment),
+		WithAudioCtx(768),
+	}},
+
+	// Slower, but fewer mistakes
+	"accuracy": {SsBeamSearch, []ParamsOption{
+		WithoutFlags(printFlags),
+		WithTokenTimestamps(),
+		WithBeam(5, 5),
+	}},
+}
+
+// PresetNames returns the names accepted by ParamsPreset, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(paramsPresets))
+	for name := range paramsPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParamsPreset returns the named preset, with opts applied on top of it
+func ParamsPreset(name string, opts ...ParamsOption) (*Params, error) {
+	preset, ok := paramsPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown params preset %q, expected one of %s", name, strings.Join(PresetNames(), ", "))
+	}
+
+	all := make([]ParamsOption, 0, len(preset.opts)+len(opts))
+	all = append(all, preset.opts...)
+	all = append(all, opts...)
+
+	this, err := NewParams(preset.strategy, all...)
+	if err != nil {
+		return nil, fmt.Errorf("params preset %q: %w", name, err)
+	}
+	this.preset = name
+	return this, nil
+}
+
+// ************************************************************
+
+func (this *Params) Strategy() eSamplingStrategy {
+	return this.strategy
+}
+
+// Preset is the name of the preset the params were created from, empty when created with NewParams
+func (this *Params) Preset() string {
+	return this.preset
+}
+
+// Window returns the part of the audio set by WithWindow, zeros when not set
+func (this *Params) Window() (offset time.Duration, duration time.Duration) {
+	if this.offset != nil {
+		offset = *this.offset
+	}
+	if this.duration != nil {
+		duration = *this.duration
+	}
+	return offset, duration
+}
+
+// ParamsSetting is one setting which differs from the DLL defaults
+type ParamsSetting struct {
+	Name  string
+	Value any
+}
+
+// Settings lists the settings, in the order Apply writes them
+func (this *Params) Settings() []ParamsSetting {
+	settings := []ParamsSetting{{"strategy", this.strategy}}
+	add := func(name string, value any) {
+		settings = append(settings, ParamsSetting{name, value})
+	}
+
+	if this.removeFlags != 0 {
+		add("removeFlags", this.removeFlags)
+	}
+	if this.addFlags != 0 {
+		add("addFlags", this.addFlags)
+	}
+	if this.cpuThreads != nil {
+		add("cpuThreads", *this.cpuThreads)
+	}
+	if this.maxTextCTX != nil {
+		add("n_max_text_ctx", *this.maxTextCTX)
+	}
+	if this.offset != nil {
+		add("offset", *this.offset)
+	}
+	if this.duration != nil {
+		add("duration", *this.duration)
+	}
+	if this.language != nil {
+		add("language", *this.language)
+	}
+	if this.tholdPt != nil {
+		add("thold_pt", *this.tholdPt)
+	}
+	if this.tholdPtsum != nil {
+		add("thold_ptsum", *this.tholdPtsum)
+	}
+	if this.maxLen != nil {
+		add("max_len", *this.maxLen)
+	}
+	if this.maxTokens != nil {
+		add("max_tokens", *this.maxTokens)
+	}
+	if this.beamWidth != nil {
+		add("beam_width", *this.beamWidth)
+		add("n_best", *this.nBest)
+	}
+	if this.audioCtx != nil {
+		add("audio_ctx", *this.audioCtx)
+	}
+
+	return settings
+}
+
+func (this *Params) String() string {
+	var sb strings.Builder
+	if this.preset != "" {
+		sb.WriteString("preset=" + this.preset + " ")
+	}
+
+	for i, setting := range this.Settings() {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		switch value := setting.Value.(type) {
+		case eFullParamsFlags:
+			fmt.Fprintf(&sb, "%s=0x%X", setting.Name, uint32(value))
+		case eSamplingStrategy:
+			fmt.Fprintf(&sb, "%s=%s", setting.Name, strategyName(value))
+		default:
+			fmt.Fprintf(&sb, "%s=%v", setting.Name, value)
+		}
+	}
+	return sb.String()
+}
+
+func strategyName(strategy eSamplingStrategy) string {
+	switch strategy {
+	case SsGreedy:
+		return "greedy"
+	case SsBeamSearch:
+		return "beamSearch"
+	}
+	return fmt.Sprintf("%d", strategy)
+}
+
+// Apply writes the settings into params, which should come from IContext.FullDefaultParams.
+// The settings are written to a copy first, so params are unchanged when one of them fails.
+func (this *Params) Apply(params *FullParams) error {
+	if err := params.check(); err != nil {
+		return err
+	}
+
+	// The copy shares the prompt tokens of params, which it never frees
+	cs := *params.cStruct
+	work := &FullParams{cStruct: &cs, context: params.context}
+
+	if work.Strategy() != this.strategy {
+		if err := work.SetStrategy(this.strategy); err != nil {
+			return err
+		}
+	}
+
+	work.RemoveFlags(this.removeFlags)
+	work.AddFlags(this.addFlags)
+
+	// Keep the first error, the options were validated already so this is not expected
+	var err error
+	set := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+
+	if this.cpuThreads != nil {
+		set(work.SetCpuThreads(*this.cpuThreads))
+	}
+	if this.maxTextCTX != nil {
+		set(work.SetMaxTextCTX(*this.maxTextCTX))
+	}
+	if this.offset != nil {
+		set(work.SetOffset(*this.offset))
+	}
+	if this.duration != nil {
+		set(work.SetDuration(*this.duration))
+	}
+	if this.language != nil {
+		set(work.SetLanguage(*this.language))
+	}
+	if this.tholdPt != nil {
+		set(work.SetTholdPt(*this.tholdPt))
+	}
+	if this.tholdPtsum != nil {
+		set(work.SetTholdPtsum(*this.tholdPtsum))
+	}
+	if this.maxLen != nil {
+		set(work.SetMaxLen(*this.maxLen))
+	}
+	if this.maxTokens != nil {
+		set(work.SetMaxTokens(*this.maxTokens))
+	}
+	if this.beamWidth != nil {
+		set(work.SetBeamSearch(*this.beamWidth, *this.nBest))
+	}
+	if this.audioCtx != nil {
+		set(work.SetAudioCtx(*this.audioCtx))
+	}
+
+	if err != nil {
+		return err
+	}
+	*params.cStruct = cs
+	return nil
+}
@@ -0,0 +1,148 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParamsPresets(t *testing.T) {
+	names := PresetNames()
+	if strings.Join(names, ",") != "accuracy,live,subtitles" {
+		t.Fatalf("presets %v", names)
+	}
+
+	for _, name := range names {
+		p, err := ParamsPreset(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if p.Preset() != name {
+			t.Errorf("%s: Preset() = %q", name, p.Preset())
+		}
+
+		params := testFullParams()
+		if err := p.Apply(params); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if params.Flags()&printFlags != 0 {
+			t.Errorf("%s prints, flags 0x%X", name, params.Flags())
+		}
+		if params.Strategy() != p.Strategy() {
+			t.Errorf("%s: strategy %v, expected %v", name, params.Strategy(), p.Strategy())
+		}
+	}
+
+	params := testFullParams()
+	p, _ := ParamsPreset("subtitles")
+	p.Apply(params)
+	if params.MaxLen() != 42 || !params.HasFlags(FlagTokenTimestamps) {
+		t.Errorf("subtitles: max_len %d, flags 0x%X", params.MaxLen(), params.Flags())
+	}
+
+	params = testFullParams()
+	p, _ = ParamsPreset("accuracy")
+	p.Apply(params)
+	if width, best := params.BeamSearch(); params.Strategy() != SsBeamSearch || width != 5 || best != 5 {
+		t.Errorf("accuracy: strategy %v, beam %d, %d", params.Strategy(), width, best)
+	}
+
+	params = testFullParams()
+	p, _ = ParamsPreset("live", WithAudioCtx(512), WithLanguage(German))
+	p.Apply(params)
+	if params.AudioCtx() != 512 || params.Language() != German || !params.HasFlags(FlagNoContext|FlagSingleSegment) {
+		t.Errorf("live: audio_ctx %d, language %v, flags 0x%X", params.AudioCtx(), params.Language(), params.Flags())
+	}
+
+	if _, err := ParamsPreset("fast"); err == nil || !strings.Contains(err.Error(), "accuracy, live, subtitles") {
+		t.Errorf("unknown preset returned %v", err)
+	}
+	if _, err := ParamsPreset("live", WithMaxLen(-1)); err == nil {
+		t.Error("an invalid option was accepted on top of a preset")
+	}
+}
+
+func TestParamsOptionsValidated(t *testing.T) {
+	cases := map[string][]ParamsOption{
+		"unknown strategy":       {WithStrategy(SsINVALIDARG)},
+		"unknown language":       {WithLanguage(eLanguage(1))},
+		"negative offset":        {WithWindow(-time.Second, 0)},
+		"negative duration":      {WithWindow(0, -time.Second)},
+		"beam width 0":           {WithStrategy(SsBeamSearch), WithBeam(0, 1)},
+		"n_best over beam width": {WithStrategy(SsBeamSearch), WithBeam(3, 4)},
+		"beam with greedy":       {WithBeam(5, 5)},
+		"negative threads":       {WithCpuThreads(-1)},
+		"negative text ctx":      {WithMaxTextCTX(-1)},
+		"threshold over 1":       {WithThresholds(2, 0)},
+		"negative max_len":       {WithMaxLen(-1)},
+		"negative max_tokens":    {WithMaxTokens(-1)},
+		"audio ctx too large":    {WithAudioCtx(maxAudioCtx + 1)},
+	}
+
+	for name, opts := range cases {
+		if _, err := NewParams(SsGreedy, opts...); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+	if _, err := NewParams(SsINVALIDARG); err == nil {
+		t.Error("an unknown strategy was accepted")
+	}
+}
+
+func TestParamsApply(t *testing.T) {
+	p, err := NewParams(SsGreedy,
+		WithFlags(FlagTranslate|FlagNoContext),
+		WithoutFlags(FlagNoContext|FlagPrintProgress),
+		WithWindow(2*time.Second, 10*time.Second),
+		WithThresholds(0.25, 0.5),
+		WithMaxTokens(30),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := testFullParams()
+	if err := p.Apply(params); err != nil {
+		t.Fatal(err)
+	}
+	if params.Flags() != FlagTranslate|FlagPrintTimestamps {
+		t.Errorf("flags 0x%X", params.Flags())
+	}
+	if params.Offset() != 2*time.Second || params.Duration() != 10*time.Second {
+		t.Errorf("window %v, %v", params.Offset(), params.Duration())
+	}
+	if params.TholdPt() != 0.25 || params.TholdPtsum() != 0.5 || params.MaxTokens() != 30 {
+		t.Errorf("thresholds %v %v, max_tokens %d", params.TholdPt(), params.TholdPtsum(), params.MaxTokens())
+	}
+	if params.Language() != English || params.MaxTextCTX() != 16384 {
+		t.Error("settings which were not given changed")
+	}
+
+	// Switching back to greedy drops the beam
+	p, err = NewParams(SsBeamSearch, WithBeam(4, 2), WithStrategy(SsGreedy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(p.String(), "beam") {
+		t.Errorf("String() = %q", p.String())
+	}
+
+	if err := p.Apply(nil); err == nil {
+		t.Error("nil params were accepted")
+	}
+}
+
+func TestParamsApplyFailureLeavesParams(t *testing.T) {
+	// Not possible with the options, which are validated, but the FullParams setters have the last word
+	maxLen := int32(-1)
+	p := &Params{strategy: SsBeamSearch, addFlags: FlagTranslate, maxLen: &maxLen}
+
+	params := testFullParams()
+	before := *params.cStruct
+	if err := p.Apply(params); err == nil {
+		t.Fatal("a negative max_len was applied")
+	}
+	if *params.cStruct != before {
+		t.Fatalf("the failed Apply changed the params\n%+v\n%+v", *params.cStruct, before)
+	}
+}
@@ -0,0 +1,150 @@
+package whisper
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress of a transcription, the same for RunFull, RunStreamed and the chunked pipelines
+type Progress struct {
+	// Transcribed part of the audio, from 0 to 1
+	Fraction float64
+	// Time since the transcription started
+	Elapsed time.Duration
+	// Estimated time until the transcription completes, 0 while unknown
+	ETA time.Duration
+}
+
+func (this Progress) String() string {
+	if this.ETA <= 0 {
+		return fmt.Sprintf("%.1f%%, %v elapsed", this.Fraction*100, this.Elapsed.Round(time.Second))
+	}
+	return fmt.Sprintf("%.1f%%, %v elapsed, %v left", this.Fraction*100, this.Elapsed.Round(time.Second), this.ETA.Round(time.Second))
+}
+
+// DefaultProgressInterval is the minimum time between two progress updates
+const DefaultProgressInterval = 250 * time.Millisecond
+
+// ProgressReporter forwards progress updates to a function, at most one per interval.
+// The fraction never goes backwards, and the final update with Fraction = 1 is always delivered, exactly once.
+// A nil *ProgressReporter ignores every update.
+type ProgressReporter struct {
+	fn       func(Progress)
+	interval time.Duration
+
+	mutex    sync.Mutex
+	start    time.Time
+	last     time.Time
+	fraction float64
+	done     bool
+
+	// Held while calling fn, so the updates are delivered one at a time and in order
+	calls sync.Mutex
+
+	// time.Now, replaced by the tests
+	now func() time.Time
+}
+
+// NewProgressReporter creates a reporter calling fn at most once per interval, DefaultProgressInterval when 0.
+// fn is called on the thread running the transcription, and should return quickly.
+func NewProgressReporter(fn func(Progress), interval time.Duration) *ProgressReporter {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	return &ProgressReporter{fn: fn, interval: interval, now: time.Now}
+}
+
+// NewProgressChannel creates a reporter sending to the channel without ever blocking the transcription:
+// an update is dropped when the channel is full, except the final one which replaces the oldest pending update.
+// The channel is not closed.
+func NewProgressChannel(ch chan Progress, interval time.Duration) *ProgressReporter {
+	return NewProgressReporter(func(p Progress) {
+		select {
+		case ch <- p:
+			return
+		default:
+		}
+		if p.Fraction < 1 {
+			return
+		}
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- p:
+		default:
+		}
+	}, interval)
+}
+
+// Start restarts the clock and the fraction, the Run methods call it before transcribing
+func (this *ProgressReporter) Start() {
+	if this == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.start = this.now()
+	this.last = time.Time{}
+	this.fraction = 0
+	this.done = false
+}
+
+// Report updates the fraction, and calls the function unless the previous call was less than the interval ago
+func (this *ProgressReporter) Report(fraction float64) {
+	if this == nil {
+		return
+	}
+	if fraction < 0 || fraction != fraction {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	this.mutex.Lock()
+	now := this.now()
+	if this.start.IsZero() {
+		this.start = now
+	}
+	if this.done {
+		this.mutex.Unlock()
+		return
+	}
+	if fraction < this.fraction {
+		fraction = this.fraction
+	}
+	this.fraction = fraction
+
+	if fraction < 1 && !this.last.IsZero() && now.Sub(this.last) < this.interval {
+		this.mutex.Unlock()
+		return
+	}
+	this.last = now
+	this.done = fraction >= 1
+	progress := newProgress(fraction, now.Sub(this.start))
+
+	this.calls.Lock()
+	this.mutex.Unlock()
+	defer this.calls.Unlock()
+
+	if this.fn != nil {
+		this.fn(progress)
+	}
+}
+
+// Done reports the completion
+func (this *ProgressReporter) Done() {
+	this.Report(1)
+}
+
+func newProgress(fraction float64, elapsed time.Duration) Progress {
+	progress := Progress{Fraction: fraction, Elapsed: elapsed}
+	if fraction > 0 && fraction < 1 {
+		progress.ETA = time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	}
+	return progress
+}
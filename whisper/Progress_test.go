@@ -0,0 +1,148 @@
+package whisper
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testReporter returns a reporter on a clock which only moves with advance, and the updates it delivered
+func testReporter(interval time.Duration) (*ProgressReporter, func(time.Duration), *[]Progress) {
+	var updates []Progress
+	reporter := NewProgressReporter(func(p Progress) {
+		updates = append(updates, p)
+	}, interval)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+	advance := func(d time.Duration) { now = now.Add(d) }
+	return reporter, advance, &updates
+}
+
+func TestProgressThrottled(t *testing.T) {
+	reporter, advance, updates := testReporter(time.Second)
+	reporter.Start()
+
+	advance(100 * time.Millisecond)
+	reporter.Report(0.1)
+	advance(500 * time.Millisecond)
+	reporter.Report(0.2)
+	advance(499 * time.Millisecond)
+	reporter.Report(0.25)
+	advance(time.Millisecond)
+	reporter.Report(0.3)
+	advance(999 * time.Millisecond)
+	reporter.Report(0.4)
+	advance(time.Millisecond)
+	reporter.Report(0.5)
+
+	// The first update, then one per second since the previous update
+	expected := []Progress{
+		{Fraction: 0.1, Elapsed: 100 * time.Millisecond, ETA: 900 * time.Millisecond},
+		{Fraction: 0.3, Elapsed: 1100 * time.Millisecond, ETA: 1100 * time.Millisecond * 7 / 3},
+		{Fraction: 0.5, Elapsed: 2100 * time.Millisecond, ETA: 2100 * time.Millisecond},
+	}
+	if len(*updates) != len(expected) {
+		t.Fatalf("updates %+v", *updates)
+	}
+	for i, p := range *updates {
+		if math.Abs(p.Fraction-expected[i].Fraction) > 1e-9 || p.Elapsed != expected[i].Elapsed || (p.ETA-expected[i].ETA).Abs() > time.Microsecond {
+			t.Errorf("update %d: %+v, expected %+v", i, p, expected[i])
+		}
+	}
+}
+
+func TestProgressMonotonic(t *testing.T) {
+	reporter, advance, updates := testReporter(time.Second)
+	reporter.Start()
+
+	for _, fraction := range []float64{0.5, 0.3, math.NaN(), -1, 0.6} {
+		advance(time.Second)
+		reporter.Report(fraction)
+	}
+
+	var fractions []float64
+	for _, p := range *updates {
+		fractions = append(fractions, p.Fraction)
+	}
+	expected := []float64{0.5, 0.5, 0.5, 0.5, 0.6}
+	if len(fractions) != len(expected) {
+		t.Fatalf("fractions %v", fractions)
+	}
+	for i := range expected {
+		if fractions[i] != expected[i] {
+			t.Fatalf("fractions %v, expected %v", fractions, expected)
+		}
+	}
+}
+
+func TestProgressDone(t *testing.T) {
+	reporter, advance, updates := testReporter(time.Minute)
+	reporter.Start()
+
+	advance(time.Second)
+	reporter.Report(0.5)
+	// Within the interval, but the final update is never throttled
+	advance(time.Second)
+	reporter.Done()
+	reporter.Done()
+	reporter.Report(0.7)
+
+	if len(*updates) != 2 {
+		t.Fatalf("updates %+v", *updates)
+	}
+	final := (*updates)[1]
+	if final.Fraction != 1 || final.ETA != 0 || final.Elapsed != 2*time.Second {
+		t.Fatalf("final update %+v", final)
+	}
+
+	// Start allows a new run, fractions past 1 complete it
+	reporter.Start()
+	advance(time.Second)
+	reporter.Report(2)
+	if len(*updates) != 3 || (*updates)[2].Fraction != 1 || (*updates)[2].Elapsed != time.Second {
+		t.Fatalf("updates after Start %+v", *updates)
+	}
+
+	var nilReporter *ProgressReporter
+	nilReporter.Start()
+	nilReporter.Report(0.5)
+	nilReporter.Done()
+}
+
+func TestProgressChannel(t *testing.T) {
+	ch := make(chan Progress, 1)
+	reporter := NewProgressChannel(ch, time.Nanosecond)
+	now := time.Now()
+	reporter.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	reporter.Start()
+	reporter.Report(0.25)
+	// Dropped, the channel is full
+	reporter.Report(0.5)
+	// Replaces the pending update
+	reporter.Done()
+
+	if p := <-ch; p.Fraction != 1 {
+		t.Fatalf("received %+v, expected the final update", p)
+	}
+	select {
+	case p := <-ch:
+		t.Fatalf("received %+v after the final update", p)
+	default:
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	p := Progress{Fraction: 0.25, Elapsed: 10 * time.Second}
+	if p.String() != "25.0%, 10s elapsed" {
+		t.Errorf("%q", p.String())
+	}
+	p.ETA = 30 * time.Second
+	if p.String() != "25.0%, 10s elapsed, 30s left" {
+		t.Errorf("%q", p.String())
+	}
+}
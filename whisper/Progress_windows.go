@@ -0,0 +1,186 @@
+package whisper
+
+import (
+	"math"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// progressRun tracks the progress of one RunFull or RunStreamed call, and delivers its new segments.
+// The fraction comes from the progress sink of runStreamed, the end of the last transcribed segment
+// and, for Go readers, the position of the reader.
+type progressRun struct {
+	reporter *ProgressReporter
+	// Part of the audio being transcribed, total is 0 when unknown
+	begin, total time.Duration
+	// Position of a Go reader, nil for native readers
+	position func() time.Duration
+
+	onSegments func(segments []Segment)
+	flags      eResultFlags
+
+	// The new segment callback of the caller, called after ours
+	prevCallback, prevData uintptr
+
+	mutex       sync.Mutex
+	transcribed time.Duration
+	// The last value of the progress sink, from 0 to 1
+	native float64
+}
+
+// HRESULT( __stdcall* pfnReportProgress )( double val, void* pv )
+// The double is passed in XMM0 which Go callbacks can't read, progressSink moves it to the first integer argument.
+var progressValueCallback = syscall.NewCallback(func(bits uintptr, pv uintptr) uintptr {
+	if run, ok := handles.get(pv).(*progressRun); ok {
+		run.nativeProgress(math.Float64frombits(uint64(bits)))
+	}
+	return uintptr(S_OK)
+})
+
+// Without the thunk the value is lost, and the call only triggers an update
+var progressSinkCallback = syscall.NewCallback(func(_ uintptr, pv uintptr) uintptr {
+	if run, ok := handles.get(pv).(*progressRun); ok {
+		run.update()
+	}
+	return uintptr(S_OK)
+})
+
+var (
+	progressSinkOnce  sync.Once
+	progressSinkThunk uintptr
+)
+
+// progressSink returns the pfnReportProgress of the runs, created once since it is never freed
+func progressSink() uintptr {
+	progressSinkOnce.Do(func() {
+		thunk, err := newFloatThunk(progressValueCallback)
+		if err != nil {
+			progressSinkThunk = progressSinkCallback
+			return
+		}
+		progressSinkThunk = thunk
+	})
+	return progressSinkThunk
+}
+
+// using pfnNewSegment = HRESULT( __cdecl* )( iContext* ctx, uint32_t n_new, void* user_data ) noexcept;
+var progressSegmentCallback = syscall.NewCallbackCDecl(func(ctx *IContext, nNew uintptr, pv uintptr) uintptr {
+	run, ok := handles.get(pv).(*progressRun)
+	if !ok {
+		return uintptr(S_OK)
+	}
+
+	run.segments(ctx, int(uint32(nNew)))
+
+	if run.prevCallback != 0 {
+		ret, _, _ := syscall.SyscallN(run.prevCallback, uintptr(unsafe.Pointer(ctx)), nNew, run.prevData)
+		return ret
+	}
+	return uintptr(S_OK)
+})
+
+// startRun installs the callbacks of the options into the params, and returns the sink for runStreamed.
+// The returned function restores the params, and reports the completion when the run succeeded.
+func startRun(params *FullParams, options RunOptions, begin, total time.Duration, position func() time.Duration) (sProgressSink, func(err error)) {
+	if options.Progress == nil && options.OnSegments == nil {
+		return sProgressSink{}, func(error) {}
+	}
+
+	cs := params.cStruct
+	run := &progressRun{
+		reporter:     options.Progress,
+		begin:        begin,
+		total:        total,
+		position:     position,
+		onSegments:   options.OnSegments,
+		flags:        options.Flags,
+		prevCallback: cs.new_segment_callback,
+		prevData:     cs.new_segment_callback_user_data,
+		transcribed:  begin,
+	}
+	handle := handles.add(run)
+
+	cs.new_segment_callback = progressSegmentCallback
+	cs.new_segment_callback_user_data = handle
+	run.reporter.Start()
+	run.reporter.Report(0)
+
+	stop := func(err error) {
+		cs.new_segment_callback, cs.new_segment_callback_user_data = run.prevCallback, run.prevData
+		handles.remove(handle)
+		if err == nil {
+			run.reporter.Done()
+		}
+	}
+	return sProgressSink{pfn: progressSink(), pv: handle}, stop
+}
+
+// segments delivers the new segments, and records the end of the last one.
+// Only the new segments are copied, or the last one when nobody wants them.
+func (this *progressRun) segments(ctx *IContext, nNew int) {
+	count, flags := nNew, this.flags
+	if this.onSegments == nil {
+		count, flags = 1, RfNone
+	}
+
+	segments, err := ctx.lastSegments(flags, count)
+	if err != nil || len(segments) == 0 {
+		return
+	}
+	end := segments[len(segments)-1].End
+
+	if this.onSegments != nil && nNew > 0 {
+		this.onSegments(segments)
+	}
+
+	this.mutex.Lock()
+	if end > this.transcribed {
+		this.transcribed = end
+	}
+	this.mutex.Unlock()
+
+	this.update()
+}
+
+// nativeProgress records the value of the progress sink
+func (this *progressRun) nativeProgress(value float64) {
+	if value != value {
+		return
+	}
+
+	this.mutex.Lock()
+	if value > this.native {
+		this.native = value
+	}
+	this.mutex.Unlock()
+
+	this.update()
+}
+
+func (this *progressRun) update() {
+	if this.reporter == nil {
+		return
+	}
+
+	this.mutex.Lock()
+	position := this.transcribed
+	native := this.native
+	this.mutex.Unlock()
+
+	if this.position != nil {
+		if p := this.position(); p > position {
+			position = p
+		}
+	}
+
+	// Without a total only the value of the progress sink is known
+	fraction := native
+	if this.total > 0 {
+		if f := float64(position-this.begin) / float64(this.total); f > fraction {
+			fraction = f
+		}
+	}
+	this.reporter.Report(fraction)
+}
@@ -0,0 +1,129 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Segment is a Go owned copy of a native sSegment, together with the tokens it references.
+// Unlike sSegment it stays valid after the ITranscribeResult is released or the context runs again.
+type Segment struct {
+	Text  string        `json:"text"`
+	Begin time.Duration `json:"begin"`
+	End   time.Duration `json:"end"`
+
+	// Empty unless the results were requested with RfTokens
+	Tokens []Token `json:"tokens,omitempty"`
+}
+
+// Token is a Go owned copy of a native SToken
+type Token struct {
+	// Raw token text, for some languages this may not be valid UTF-8 on its own.
+	// Serialised as "bytes" rather than "text" when it is not valid UTF-8, see Token.MarshalJSON
+	Text  string
+	Begin time.Duration
+	End   time.Duration
+
+	Probability          float32
+	ProbabilityTimestamp float32
+	Ptsum                float32
+	Vlen                 float32
+	Id                   int32
+	Flags                eTokenFlags
+}
+
+func (this *Token) IsSpecial() bool {
+	return (this.Flags & TfSpecial) != 0
+}
+
+// Duration converts the 100-nanosecond ticks to a time.Duration
+func (this sTimeSpan) Duration() time.Duration {
+	return time.Duration(this.Ticks) * 100
+}
+
+func newToken(tok *SToken) Token {
+	return Token{
+		Text:                 tok.Text(),
+		Begin:                tok.Time.Begin.Duration(),
+		End:                  tok.Time.End.Duration(),
+		Probability:          tok.Probability,
+		ProbabilityTimestamp: tok.ProbabilityTimestamp,
+		Ptsum:                tok.Ptsum,
+		Vlen:                 tok.Vlen,
+		Id:                   tok.Id,
+		Flags:                tok.Flags,
+	}
+}
+
+// newSegment copies the segment, and its slice of tokens when tokens is not empty
+func newSegment(seg *sSegment, tokens []SToken) (Segment, error) {
+	result := Segment{
+		Text:  seg.Text(),
+		Begin: seg.Time.Begin.Duration(),
+		End:   seg.Time.End.Duration(),
+	}
+
+	if len(tokens) == 0 || seg.CountTokens == 0 {
+		return result, nil
+	}
+
+	segTokens, err := seg.Tokens(tokens)
+	if err != nil {
+		return result, err
+	}
+
+	result.Tokens = make([]Token, len(segTokens))
+	for i := range segTokens {
+		result.Tokens[i] = newToken(&segTokens[i])
+	}
+
+	return result, nil
+}
+
+// SegmentsFromResult copies every segment of the result, and their tokens if the result has any, into Go memory
+func SegmentsFromResult(result *ITranscribeResult) ([]Segment, error) {
+	if result == nil {
+		return nil, errors.New("SegmentsFromResult: result is nil")
+	}
+
+	segments, tokens, err := result.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make([]Segment, 0, len(segments))
+	for i := range segments {
+		seg, err := newSegment(&segments[i], tokens)
+		if err != nil {
+			return nil, fmt.Errorf("SegmentsFromResult: segment %d: %w", i, err)
+		}
+		copied = append(copied, seg)
+	}
+
+	return copied, nil
+}
+
+// lastSegments copies the last count segments of the result, only checking the token ranges of those
+func lastSegments(result *ITranscribeResult, count int) ([]Segment, error) {
+	segments, tokens, err := result.views()
+	if err != nil {
+		return nil, err
+	}
+
+	first := len(segments) - count
+	if first < 0 {
+		first = 0
+	}
+
+	copied := make([]Segment, 0, len(segments)-first)
+	for i := first; i < len(segments); i++ {
+		seg, err := newSegment(&segments[i], tokens)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", i, err)
+		}
+		copied = append(copied, seg)
+	}
+
+	return copied, nil
+}
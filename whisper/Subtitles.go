@@ -0,0 +1,229 @@
+package whisper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// https://en.wikipedia.org/wiki/SubRip
+// https://www.w3.org/TR/webvtt1/
+
+// VTTCueSettings are appended to every cue timing line, empty fields are omitted.
+// e.g. Line: "0", Align: "start" produces "line:0 align:start"
+type VTTCueSettings struct {
+	Vertical string // "rl" or "lr"
+	Line     string // "0", "-1", "50%,end"
+	Position string // "10%", "10%,line-left"
+	Size     string // "80%"
+	Align    string // "start", "center", "end", "left", "right"
+}
+
+func (this *VTTCueSettings) String() string {
+	if this == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, 5)
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+":"+value)
+		}
+	}
+	add("vertical", this.Vertical)
+	add("line", this.Line)
+	add("position", this.Position)
+	add("size", this.Size)
+	add("align", this.Align)
+
+	return strings.Join(parts, " ")
+}
+
+func (this *VTTCueSettings) validate() error {
+	if this == nil {
+		return nil
+	}
+
+	for _, value := range []string{this.Vertical, this.Line, this.Position, this.Size, this.Align} {
+		if strings.ContainsAny(value, " \t\r\n:") || strings.Contains(value, "-->") {
+			return fmt.Errorf("invalid WebVTT cue setting value %q", value)
+		}
+	}
+
+	return nil
+}
+
+type VTTOptions struct {
+	// Write the cue number as the cue identifier
+	CueIds bool
+
+	// Optional settings written after the timings of every cue
+	Settings *VTTCueSettings
+
+	// Use the segment tokens to emit inline timestamps, for karaoke style captions.
+	// Segments without tokens are written as plain text.
+	TokenTimestamps bool
+}
+
+// WriteSRT writes the segments as SubRip subtitles. Segments without text are skipped.
+func WriteSRT(w io.Writer, segments []Segment) error {
+	ew := &errWriter{w: w}
+
+	cue := 0
+	for i := range segments {
+		// SRT has no escaping, so keep the text from looking like a timing line
+		text := strings.ReplaceAll(cueText(segments[i].Text), "-->", "->")
+		if text == "" {
+			continue
+		}
+
+		cue++
+		ew.printf("%d\n%s --> %s\n%s\n\n", cue,
+			formatTimecode(segments[i].Begin, ','),
+			formatTimecode(segments[i].End, ','),
+			text)
+	}
+
+	return ew.err
+}
+
+// WriteVTT writes the segments as WebVTT. options may be nil.
+func WriteVTT(w io.Writer, segments []Segment, options *VTTOptions) error {
+	if options == nil {
+		options = &VTTOptions{}
+	}
+
+	if err := options.Settings.validate(); err != nil {
+		return err
+	}
+	settings := options.Settings.String()
+
+	ew := &errWriter{w: w}
+	ew.printf("WEBVTT\n\n")
+
+	cue := 0
+	for i := range segments {
+		seg := &segments[i]
+
+		var text string
+		if options.TokenTimestamps && len(seg.Tokens) > 0 {
+			text = vttTokenText(seg)
+		} else {
+			text = escapeVTT(cueText(seg.Text))
+		}
+		if text == "" {
+			continue
+		}
+
+		cue++
+		if options.CueIds {
+			ew.printf("%d\n", cue)
+		}
+
+		ew.printf("%s --> %s", formatTimecode(seg.Begin, '.'), formatTimecode(seg.End, '.'))
+		if settings != "" {
+			ew.printf(" %s", settings)
+		}
+		ew.printf("\n%s\n\n", text)
+	}
+
+	return ew.err
+}
+
+// formatTimecode formats HH:MM:SS,mmm for SRT and HH:MM:SS.mmm for WebVTT, rounded to the nearest millisecond.
+// Hours are not limited to 2 digits.
+func formatTimecode(d time.Duration, separator byte) string {
+	if d < 0 {
+		d = 0
+	}
+
+	ms := int64((d + time.Millisecond/2) / time.Millisecond)
+
+	hours := ms / 3600000
+	ms -= hours * 3600000
+	minutes := ms / 60000
+	ms -= minutes * 60000
+	seconds := ms / 1000
+	ms -= seconds * 1000
+
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", hours, minutes, seconds, separator, ms)
+}
+
+// cueText trims the text, and removes blank lines which would terminate the cue early
+func cueText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeVTT(text string) string {
+	return vttEscaper.Replace(text)
+}
+
+// Line breaks would end the cue, within a cue they are spaces
+var vttLineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+// vttTokenText joins the non special tokens of the segment, with a timestamp tag before every token but the first.
+// The tokens are repaired first, so no tag lands within a code point split between two tokens.
+func vttTokenText(seg *Segment) string {
+	var sb strings.Builder
+
+	tokens, _ := seg.RepairedTokens()
+	first := true
+	for i := range tokens {
+		tok := &tokens[i]
+		if tok.IsSpecial() {
+			continue
+		}
+
+		text := vttLineBreaks.Replace(tok.Text)
+		if first {
+			text = strings.TrimLeft(text, " \t")
+			if text == "" {
+				continue
+			}
+			sb.WriteString(escapeVTT(text))
+			first = false
+			continue
+		}
+
+		// Keep the word separator outside of the timestamp tag
+		word := strings.TrimLeft(text, " \t")
+		if len(word) != len(text) {
+			sb.WriteByte(' ')
+		}
+		if word == "" {
+			continue
+		}
+		sb.WriteString("<" + formatTimecode(tok.Begin, '.') + ">")
+		sb.WriteString(escapeVTT(word))
+	}
+
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// errWriter keeps the first write error, so the writers don't have to check every call
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (this *errWriter) printf(format string, args ...any) {
+	if this.err != nil {
+		return
+	}
+	_, this.err = fmt.Fprintf(this.w, format, args...)
+}
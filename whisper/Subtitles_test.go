@@ -0,0 +1,149 @@
+package whisper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestFormatTimecode(t *testing.T) {
+	cases := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "00:00:00,000"},
+		{1500 * time.Millisecond, "00:00:01,500"},
+		{time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, "01:02:03,004"},
+		{100 * time.Hour, "100:00:00,000"},
+		// Rounded to the nearest millisecond, halves up
+		{time.Second + 499*time.Microsecond, "00:00:01,000"},
+		{time.Second + 500*time.Microsecond, "00:00:01,001"},
+		{59*time.Second + 999600*time.Microsecond, "00:01:00,000"},
+		{-time.Second, "00:00:00,000"},
+	}
+
+	for _, c := range cases {
+		if got := formatTimecode(c.d, ','); got != c.expected {
+			t.Errorf("formatTimecode(%v) = %q, expected %q", c.d, got, c.expected)
+		}
+	}
+	if got := formatTimecode(1500*time.Millisecond, '.'); got != "00:00:01.500" {
+		t.Errorf("WebVTT timecode %q", got)
+	}
+}
+
+func TestWriteSRT(t *testing.T) {
+	segments := []Segment{
+		{Text: " Hello there.", Begin: 0, End: 1500 * time.Millisecond},
+		{Text: "   ", Begin: 1500 * time.Millisecond, End: 2 * time.Second},
+		{Text: "first line\r\n\r\nsecond --> line", Begin: 2 * time.Second, End: time.Hour + 3*time.Second},
+		{Text: "", Begin: -time.Second, End: 0},
+	}
+
+	var out bytes.Buffer
+	if err := WriteSRT(&out, segments); err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty segments are skipped without leaving a gap in the numbering
+	expected := "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n" +
+		"2\n00:00:02,000 --> 01:00:03,000\nfirst line\nsecond -> line\n\n"
+	if out.String() != expected {
+		t.Fatalf("got\n%q\nexpected\n%q", out.String(), expected)
+	}
+
+	out.Reset()
+	if err := WriteSRT(&out, nil); err != nil || out.Len() != 0 {
+		t.Fatalf("no segments wrote %q, %v", out.String(), err)
+	}
+}
+
+func TestWriteVTT(t *testing.T) {
+	segments := []Segment{
+		{Text: "Tom & Jerry <3", Begin: 1 * time.Second, End: 2 * time.Second},
+		{Text: "\n", Begin: 2 * time.Second, End: 3 * time.Second},
+		{Text: "second", Begin: 3 * time.Second, End: 4*time.Second + 250*time.Millisecond},
+	}
+
+	var out bytes.Buffer
+	if err := WriteVTT(&out, segments, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:02.000\nTom &amp; Jerry &lt;3\n\n" +
+		"00:00:03.000 --> 00:00:04.250\nsecond\n\n"
+	if out.String() != expected {
+		t.Fatalf("got\n%q\nexpected\n%q", out.String(), expected)
+	}
+
+	out.Reset()
+	options := &VTTOptions{CueIds: true, Settings: &VTTCueSettings{Line: "0", Align: "start"}}
+	if err := WriteVTT(&out, segments, options); err != nil {
+		t.Fatal(err)
+	}
+	expected = "WEBVTT\n\n" +
+		"1\n00:00:01.000 --> 00:00:02.000 line:0 align:start\nTom &amp; Jerry &lt;3\n\n" +
+		"2\n00:00:03.000 --> 00:00:04.250 line:0 align:start\nsecond\n\n"
+	if out.String() != expected {
+		t.Fatalf("got\n%q\nexpected\n%q", out.String(), expected)
+	}
+
+	for _, bad := range []string{"a b", "x:y", "-->", "a\nb"} {
+		options := &VTTOptions{Settings: &VTTCueSettings{Position: bad}}
+		if err := WriteVTT(&bytes.Buffer{}, segments, options); err == nil {
+			t.Errorf("cue setting %q was accepted", bad)
+		}
+	}
+}
+
+func TestWriteVTTTokenTimestamps(t *testing.T) {
+	segments := []Segment{{
+		Text:  " Hello big world",
+		Begin: 0,
+		End:   3 * time.Second,
+		Tokens: []Token{
+			{Text: "[_BEG_]", Flags: TfSpecial},
+			{Text: " Hello", Begin: 0},
+			{Text: " big", Begin: time.Second},
+			{Text: " wor", Begin: 2 * time.Second},
+			{Text: "ld<", Begin: 2500 * time.Millisecond},
+			{Text: "[_TT_150]", Flags: TfSpecial},
+		},
+	}}
+
+	var out bytes.Buffer
+	if err := WriteVTT(&out, segments, &VTTOptions{TokenTimestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "WEBVTT\n\n00:00:00.000 --> 00:00:03.000\n" +
+		"Hello <00:00:01.000>big <00:00:02.000>wor<00:00:02.500>ld&lt;\n\n"
+	if out.String() != expected {
+		t.Fatalf("got\n%q\nexpected\n%q", out.String(), expected)
+	}
+}
+
+func TestWriteVTTSplitCodePoint(t *testing.T) {
+	// 你好 with the bytes of 你 split between the first two tokens
+	segments := []Segment{{
+		Text: "你好",
+		End:  2 * time.Second,
+		Tokens: []Token{
+			{Text: "\xE4\xBD", Begin: 0, End: time.Second},
+			{Text: "\xA0", Begin: time.Second, End: time.Second},
+			{Text: "好", Begin: time.Second, End: 2 * time.Second},
+		},
+	}}
+
+	var out bytes.Buffer
+	if err := WriteVTT(&out, segments, &VTTOptions{TokenTimestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(out.Bytes()) {
+		t.Fatalf("invalid UTF-8 %q", out.String())
+	}
+	if !strings.Contains(out.String(), "\n你<00:00:01.000>好\n") {
+		t.Fatalf("got %q", out.String())
+	}
+}
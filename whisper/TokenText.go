@@ -0,0 +1,105 @@
+package whisper
+
+import (
+	"unicode/utf8"
+)
+
+// For some languages, Chinese in particular, the model emits tokens which are not valid UTF-8 on their own:
+// a code point is split between adjacent tokens of the same segment.
+// More info: https://github.com/ggerganov/whisper.cpp/issues/399
+
+// InvalidBytes are bytes of token text which never formed a complete UTF-8 code point
+type InvalidBytes struct {
+	// Index of the token in Segment.Tokens which contains the bytes
+	Token int
+	Bytes []byte
+}
+
+// RepairTokens returns a copy of the tokens where the text of every token is valid UTF-8.
+//
+// A code point split between tokens is moved into the token containing its first byte,
+// and the end time of that token is extended to the end of the token containing its last byte.
+// The tokens which gave up their bytes are kept, possibly with empty text, so indices into the slice don't change.
+// Bytes which can't be decoded are replaced with U+FFFD and reported.
+// Special tokens are copied unchanged, and don't join the text of their neighbours.
+func RepairTokens(tokens []Token) ([]Token, []InvalidBytes) {
+	repaired, invalid, _ := repairTokens(tokens)
+	return repaired, invalid
+}
+
+// repairTokens also returns, for every token, the index of the last token whose bytes were moved into it
+func repairTokens(tokens []Token) ([]Token, []InvalidBytes, []int) {
+	repaired := make([]Token, len(tokens))
+	copy(repaired, tokens)
+
+	texts := make([][]byte, len(tokens))
+	merged := make([]int, len(tokens))
+	for i := range merged {
+		merged[i] = i
+	}
+	var invalid []InvalidBytes
+
+	// The text of a run of non special tokens, with the index of the token owning every byte
+	var buffer []byte
+	var owners []int
+
+	// decode splits the text of the run back into the tokens, special tokens end a run
+	decode := func() {
+		prevInvalid := false
+
+		for offset := 0; offset < len(buffer); {
+			r, size := utf8.DecodeRune(buffer[offset:])
+			first := owners[offset]
+
+			if r == utf8.RuneError && size <= 1 {
+				// Runs of invalid bytes in the same token are one report, and one replacement character
+				if n := len(invalid); prevInvalid && invalid[n-1].Token == first {
+					invalid[n-1].Bytes = append(invalid[n-1].Bytes, buffer[offset])
+				} else {
+					invalid = append(invalid, InvalidBytes{Token: first, Bytes: []byte{buffer[offset]}})
+					texts[first] = utf8.AppendRune(texts[first], utf8.RuneError)
+				}
+				prevInvalid = true
+				offset++
+				continue
+			}
+
+			prevInvalid = false
+			texts[first] = append(texts[first], buffer[offset:offset+size]...)
+			if last := owners[offset+size-1]; last != first {
+				merged[first] = last
+				if tokens[last].End > repaired[first].End {
+					repaired[first].End = tokens[last].End
+				}
+			}
+			offset += size
+		}
+
+		buffer, owners = buffer[:0], owners[:0]
+	}
+
+	for i := range tokens {
+		if tokens[i].IsSpecial() {
+			decode()
+			continue
+		}
+		buffer = append(buffer, tokens[i].Text...)
+		for j := 0; j < len(tokens[i].Text); j++ {
+			owners = append(owners, i)
+		}
+	}
+	decode()
+
+	for i := range tokens {
+		if !tokens[i].IsSpecial() {
+			repaired[i].Text = string(texts[i])
+		}
+	}
+
+	return repaired, invalid, merged
+}
+
+// RepairedTokens returns the tokens of the segment with code points split between tokens joined, see RepairTokens
+func (this *Segment) RepairedTokens() ([]Token, []InvalidBytes) {
+	return RepairTokens(this.Tokens)
+}
@@ -0,0 +1,105 @@
+package whisper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepairTokensJoinsSplitCodePoint(t *testing.T) {
+	// 你 is E4 BD A0, split over two tokens
+	tokens := []Token{
+		{Text: "\xE4\xBD", Begin: 0, End: time.Second},
+		{Text: "\xA0好", Begin: time.Second, End: 2 * time.Second},
+	}
+
+	repaired, invalid := RepairTokens(tokens)
+	if len(invalid) != 0 {
+		t.Fatalf("invalid bytes %v", invalid)
+	}
+	if repaired[0].Text != "你" || repaired[1].Text != "好" {
+		t.Fatalf("texts %q %q", repaired[0].Text, repaired[1].Text)
+	}
+	if repaired[0].End != 2*time.Second {
+		t.Fatalf("the first token ends at %v, expected the end of the second one", repaired[0].End)
+	}
+	if tokens[0].Text != "\xE4\xBD" {
+		t.Fatal("the input was modified")
+	}
+}
+
+func TestRepairTokensAcrossThreeTokens(t *testing.T) {
+	// 😀 is F0 9F 98 80, the middle token only holds a continuation byte
+	tokens := []Token{
+		{Text: " a\xF0", End: time.Second},
+		{Text: "\x9F", End: 2 * time.Second},
+		{Text: "\x98\x80", End: 3 * time.Second},
+		{Text: " b", End: 4 * time.Second},
+	}
+
+	repaired, invalid := RepairTokens(tokens)
+	if len(invalid) != 0 {
+		t.Fatalf("invalid bytes %v", invalid)
+	}
+	texts := []string{" a😀", "", "", " b"}
+	for i, text := range texts {
+		if repaired[i].Text != text {
+			t.Errorf("token %d: %q, expected %q", i, repaired[i].Text, text)
+		}
+	}
+	if repaired[0].End != 3*time.Second || repaired[3].End != 4*time.Second {
+		t.Fatalf("ends %v %v", repaired[0].End, repaired[3].End)
+	}
+}
+
+func TestRepairTokensInvalidBytes(t *testing.T) {
+	tokens := []Token{
+		{Text: "ok"},
+		{Text: "\xFF\xFEx"},
+		{Text: "\xE4\xBD"},
+	}
+
+	repaired, invalid := RepairTokens(tokens)
+	texts := []string{"ok", "�x", "�"}
+	for i, text := range texts {
+		if repaired[i].Text != text {
+			t.Errorf("token %d: %q, expected %q", i, repaired[i].Text, text)
+		}
+	}
+	// A run of invalid bytes in a token is one report
+	if len(invalid) != 2 || invalid[0].Token != 1 || string(invalid[0].Bytes) != "\xFF\xFE" ||
+		invalid[1].Token != 2 || string(invalid[1].Bytes) != "\xE4\xBD" {
+		t.Fatalf("invalid bytes %v", invalid)
+	}
+}
+
+func TestSegmentRepairedTokens(t *testing.T) {
+	seg := Segment{Tokens: []Token{{Text: "\xE4"}, {Text: "\xBD\xA0"}}}
+	repaired, invalid := seg.RepairedTokens()
+	if len(invalid) != 0 || len(repaired) != 2 || repaired[0].Text != "你" || repaired[1].Text != "" {
+		t.Fatalf("repaired %v, invalid %v", repaired, invalid)
+	}
+
+	repaired, invalid = (&Segment{}).RepairedTokens()
+	if len(repaired) != 0 || len(invalid) != 0 {
+		t.Fatalf("repaired %v, invalid %v without tokens", repaired, invalid)
+	}
+}
+
+func TestRepairTokensSpecialSeparates(t *testing.T) {
+	tokens := []Token{
+		{Text: "\xE4\xBD"},
+		{Text: "[_TT_50]", Flags: TfSpecial},
+		{Text: "\xA0"},
+	}
+
+	repaired, invalid := RepairTokens(tokens)
+	if repaired[1].Text != "[_TT_50]" {
+		t.Fatalf("the special token became %q", repaired[1].Text)
+	}
+	if repaired[0].Text != "�" || repaired[2].Text != "�" {
+		t.Fatalf("texts %q %q, the bytes around the special token were joined", repaired[0].Text, repaired[2].Text)
+	}
+	if len(invalid) != 2 || invalid[0].Token != 0 || invalid[1].Token != 2 || len(invalid[0].Bytes) != 2 {
+		t.Fatalf("invalid bytes %v", invalid)
+	}
+}
@@ -1,13 +1,8 @@
 package whisper
 
 import (
-	"C"
-	"errors"
 	"fmt"
-	"syscall"
 	"unsafe"
-
-	"golang.org/x/sys/windows"
 )
 
 type eTokenFlags uint32
@@ -50,7 +45,7 @@ type sTimeInterval struct {
 
 type sSegment struct {
 	// Segment text, null-terminated, and probably UTF-8 encoded
-	text *C.char
+	text *byte
 
 	// Start and end times of the segment
 	Time sTimeInterval
@@ -61,7 +56,7 @@ type sSegment struct {
 }
 
 func (this *sSegment) Text() string {
-	return C.GoString(this.text)
+	return goString(this.text)
 }
 
 type sSegmentArray []sSegment
@@ -70,7 +65,7 @@ type SToken struct {
 	// Token text, null-terminated, and usually UTF-8 encoded.
 	// I think for Chinese language the models sometimes outputs invalid UTF8 strings here, Unicode code points can be split between adjacent tokens in the same segment
 	// More info: https://github.com/ggerganov/whisper.cpp/issues/399
-	text *C.char
+	text *byte
 
 	// Start and end times of the token
 	Time sTimeInterval
@@ -93,7 +88,7 @@ type SToken struct {
 }
 
 func (this *SToken) Text() string {
-	return C.GoString(this.text)
+	return goString(this.text)
 }
 
 type sTokenArray []SToken
@@ -112,67 +107,65 @@ type ITranscribeResult struct {
 	lpVtbl *iTranscribeResultVtbl
 }
 
-func (this *ITranscribeResult) AddRef() int32 {
-	ret, _, _ := syscall.Syscall(
-		this.lpVtbl.AddRef,
-		1,
-		uintptr(unsafe.Pointer(this)),
-		0,
-		0)
-	return int32(ret)
+// Segments returns every segment of the result.
+// The slice points to native memory, which is only valid until the result is released or the context runs again
+func (this *ITranscribeResult) Segments() ([]sSegment, error) {
+	segments, _, err := this.Contents()
+	return segments, err
 }
 
-func (this *ITranscribeResult) Release() int32 {
-	ret, _, _ := syscall.Syscall(
-		this.lpVtbl.Release,
-		1,
-		uintptr(unsafe.Pointer(this)),
-		0,
-		0)
-	return int32(ret)
+// Tokens returns every token of the result, the slice is empty when the results were requested without RfTokens.
+// The slice points to native memory, which is only valid until the result is released or the context runs again
+func (this *ITranscribeResult) Tokens() ([]SToken, error) {
+	_, tokens, err := this.Contents()
+	return tokens, err
 }
 
-func (this *ITranscribeResult) GetSize() (*sTranscribeLength, error) {
-
-	var result sTranscribeLength
+// Tokens returns the slice of tokens belonging to this segment, nil when it has none
+func (this *sSegment) Tokens(tokens []SToken) ([]SToken, error) {
+	if this.CountTokens == 0 {
+		// FirstToken is not checked then, it may be anything
+		return nil, nil
+	}
+	if err := this.validate(uint32(len(tokens))); err != nil {
+		return nil, err
+	}
 
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.getSize,
-		uintptr(unsafe.Pointer(this)),
-		uintptr(unsafe.Pointer(&result)),
-	)
+	return tokens[this.FirstToken : this.FirstToken+this.CountTokens], nil
+}
 
-	if windows.Handle(ret) != windows.S_OK {
-		fmt.Printf("iTranscribeResult.GetSize failed: %s\n", syscall.Errno(ret).Error())
-		return nil, errors.New(syscall.Errno(ret).Error())
+// validate checks the token range of the segment, without overflowing uint32
+func (this *sSegment) validate(countTokens uint32) error {
+	if this.CountTokens == 0 {
+		return nil
 	}
 
-	return &result, nil
+	last := uint64(this.FirstToken) + uint64(this.CountTokens)
+	if last > uint64(countTokens) {
+		return fmt.Errorf("segment tokens [%d, %d) out of range, the result has %d tokens", this.FirstToken, last, countTokens)
+	}
 
+	return nil
 }
 
-func (this *ITranscribeResult) GetSegments(len uint32) []sSegment {
-
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.getSegments,
-		uintptr(unsafe.Pointer(this)),
-	)
+// validateSegments checks every segment references tokens within [0, countTokens).
+// When the results have no tokens, the token ranges are not checked, since they can't be used.
+func validateSegments(segments []sSegment, countTokens uint32) error {
+	if countTokens == 0 {
+		return nil
+	}
 
-	data := unsafe.Slice((*sSegment)(unsafe.Pointer(ret)), len)
+	for i := range segments {
+		if err := segments[i].validate(countTokens); err != nil {
+			return fmt.Errorf("segment %d: %w", i, err)
+		}
+	}
 
-	return data
+	return nil
 }
 
-func (this *ITranscribeResult) GetTokens(len uint32) []SToken {
-
-	ret, _, _ := syscall.SyscallN(
-		this.lpVtbl.getTokens,
-		uintptr(unsafe.Pointer(this)),
-	)
-
-	if unsafe.Pointer(ret) != nil {
-		return unsafe.Slice((*SToken)(unsafe.Pointer(ret)), len)
-	} else {
-		return []SToken{}
-	}
+// syscallPointer converts the address of native memory, returned by a syscall or allocated outside of the Go heap.
+// go vet flags the direct unsafe.Pointer(uintptr) conversion, as it can't tell the memory isn't Go's.
+func syscallPointer(ret uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&ret))
 }
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func (this *ITranscribeResult) AddRef() int32 {
+	return 0
+}
+
+func (this *ITranscribeResult) Release() int32 {
+	return 0
+}
+
+func (this *ITranscribeResult) GetSize() (*sTranscribeLength, error) {
+	return nil, errUnsupported("iTranscribeResult.GetSize")
+}
+
+func (this *ITranscribeResult) GetSegments(len uint32) []sSegment {
+	return []sSegment{}
+}
+
+func (this *ITranscribeResult) GetTokens(len uint32) []SToken {
+	return []SToken{}
+}
+
+func (this *ITranscribeResult) Contents() ([]sSegment, []SToken, error) {
+	return nil, nil, errUnsupported("iTranscribeResult.Contents")
+}
+
+func (this *ITranscribeResult) views() ([]sSegment, []SToken, error) {
+	return nil, nil, errUnsupported("iTranscribeResult.Contents")
+}
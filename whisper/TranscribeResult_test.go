@@ -0,0 +1,57 @@
+package whisper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSegmentTokens(t *testing.T) {
+	tokens := make([]SToken, 4)
+	for i := range tokens {
+		tokens[i].Id = int32(i)
+	}
+
+	// No tokens, with a FirstToken validateSegments doesn't check
+	empty := sSegment{FirstToken: 10}
+	if err := validateSegments([]sSegment{empty}, uint32(len(tokens))); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := empty.Tokens(tokens); got != nil || err != nil {
+		t.Fatalf("Tokens of an empty segment returned %v, %v", got, err)
+	}
+
+	seg := sSegment{FirstToken: 1, CountTokens: 2}
+	got, err := seg.Tokens(tokens)
+	if err != nil || len(got) != 2 || got[0].Id != 1 {
+		t.Fatalf("Tokens returned %v, %v", got, err)
+	}
+
+	outside := sSegment{FirstToken: 3, CountTokens: 2}
+	if _, err := outside.Tokens(tokens); err == nil {
+		t.Fatal("tokens past the end were returned")
+	}
+	if err := validateSegments([]sSegment{seg, outside}, uint32(len(tokens))); err == nil {
+		t.Fatal("validateSegments accepted tokens past the end")
+	}
+}
+
+func TestNewSegmentTimes(t *testing.T) {
+	tokens := []SToken{
+		{Time: sTimeInterval{Begin: sTimeSpan{15_000_000}, End: sTimeSpan{17_500_000}}},
+	}
+	seg := sSegment{
+		Time:        sTimeInterval{Begin: sTimeSpan{15_000_000}, End: sTimeSpan{32_000_000}},
+		CountTokens: 1,
+	}
+
+	got, err := newSegment(&seg, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Begin != 1500*time.Millisecond || got.End != 3200*time.Millisecond {
+		t.Fatalf("segment %v - %v, expected 1.5s - 3.2s", got.Begin, got.End)
+	}
+	if len(got.Tokens) != 1 || got.Tokens[0].Begin != 1500*time.Millisecond || got.Tokens[0].End != 1750*time.Millisecond {
+		t.Fatalf("tokens %+v", got.Tokens)
+	}
+}
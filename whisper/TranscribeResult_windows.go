@@ -0,0 +1,132 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func (this *ITranscribeResult) AddRef() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.AddRef,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *ITranscribeResult) Release() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.Release,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *ITranscribeResult) GetSize() (*sTranscribeLength, error) {
+
+	var result sTranscribeLength
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getSize,
+		uintptr(unsafe.Pointer(this)),
+		uintptr(unsafe.Pointer(&result)),
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("iTranscribeResult.GetSize failed: %s\n", syscall.Errno(ret).Error())
+		return nil, errors.New(syscall.Errno(ret).Error())
+	}
+
+	return &result, nil
+
+}
+
+// GetSegments returns a view of the first len segments in native memory.
+//
+// Deprecated: the caller has to guess len, use Segments which sizes the slice from getSize
+func (this *ITranscribeResult) GetSegments(len uint32) []sSegment {
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getSegments,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	if ret == 0 {
+		return []sSegment{}
+	}
+
+	return unsafe.Slice((*sSegment)(syscallPointer(ret)), len)
+}
+
+// GetTokens returns a view of the first len tokens in native memory.
+//
+// Deprecated: the caller has to guess len, use Tokens which sizes the slice from getSize
+func (this *ITranscribeResult) GetTokens(len uint32) []SToken {
+
+	ret, _, _ := syscall.SyscallN(
+		this.lpVtbl.getTokens,
+		uintptr(unsafe.Pointer(this)),
+	)
+
+	if ret == 0 {
+		return []SToken{}
+	}
+
+	return unsafe.Slice((*SToken)(syscallPointer(ret)), len)
+}
+
+// Contents returns every segment and token of the result, sized with getSize.
+// Every segment is checked to reference tokens within the returned slice, so sSegment.Tokens will not fail afterwards
+func (this *ITranscribeResult) Contents() ([]sSegment, []SToken, error) {
+	segments, tokens, err := this.views()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateSegments(segments, uint32(len(tokens))); err != nil {
+		return nil, nil, err
+	}
+
+	return segments, tokens, nil
+}
+
+// views returns the segments and tokens of the result in native memory, without checking the token ranges
+func (this *ITranscribeResult) views() ([]sSegment, []SToken, error) {
+	length, err := this.GetSize()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	segments := []sSegment{}
+	if length.CountSegments > 0 {
+		ret, _, _ := syscall.SyscallN(
+			this.lpVtbl.getSegments,
+			uintptr(unsafe.Pointer(this)),
+		)
+		if ret == 0 {
+			return nil, nil, fmt.Errorf("iTranscribeResult.getSegments returned null for %d segments", length.CountSegments)
+		}
+		segments = unsafe.Slice((*sSegment)(syscallPointer(ret)), length.CountSegments)
+	}
+
+	tokens := []SToken{}
+	if length.CountTokens > 0 {
+		ret, _, _ := syscall.SyscallN(
+			this.lpVtbl.getTokens,
+			uintptr(unsafe.Pointer(this)),
+		)
+		if ret == 0 {
+			return nil, nil, fmt.Errorf("iTranscribeResult.getTokens returned null for %d tokens", length.CountTokens)
+		}
+		tokens = unsafe.Slice((*SToken)(syscallPointer(ret)), length.CountTokens)
+	}
+
+	return segments, tokens, nil
+}
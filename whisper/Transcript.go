@@ -0,0 +1,187 @@
+package whisper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Transcript is a deep copy of an ITranscribeResult.
+// It owns all of its memory, so it can be kept, cached, or serialised after the result is released
+// and after the context runs again.
+type Transcript struct {
+	Segments []Segment `json:"segments"`
+}
+
+// NewTranscript copies the segments, and tokens if present, of the result.
+// The result is not released.
+func NewTranscript(result *ITranscribeResult) (*Transcript, error) {
+	segments, err := SegmentsFromResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transcript{Segments: segments}, nil
+}
+
+// Transcript copies the result, see NewTranscript
+func (this *ITranscribeResult) Transcript() (*Transcript, error) {
+	return NewTranscript(this)
+}
+
+// CountTokens returns the total number of tokens in all segments
+func (this *Transcript) CountTokens() int {
+	count := 0
+	for i := range this.Segments {
+		count += len(this.Segments[i].Tokens)
+	}
+	return count
+}
+
+// Duration returns the end time of the last segment
+func (this *Transcript) Duration() time.Duration {
+	if len(this.Segments) == 0 {
+		return 0
+	}
+	return this.Segments[len(this.Segments)-1].End
+}
+
+// Text joins the text of every segment
+func (this *Transcript) Text() string {
+	var sb strings.Builder
+	for i := range this.Segments {
+		sb.WriteString(this.Segments[i].Text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ForEachSegment calls fn for every segment in order, until fn returns false
+func (this *Transcript) ForEachSegment(fn func(index int, seg *Segment) bool) {
+	for i := range this.Segments {
+		if !fn(i, &this.Segments[i]) {
+			return
+		}
+	}
+}
+
+// ForEachToken calls fn for every token of every segment in order, until fn returns false.
+// segment is the index of the segment the token belongs to.
+func (this *Transcript) ForEachToken(fn func(segment int, tok *Token) bool) {
+	for i := range this.Segments {
+		tokens := this.Segments[i].Tokens
+		for j := range tokens {
+			if !fn(i, &tokens[j]) {
+				return
+			}
+		}
+	}
+}
+
+// Shift moves every segment and token by offset, used when the audio was transcribed from a later position
+func (this *Transcript) Shift(offset time.Duration) {
+	for i := range this.Segments {
+		seg := &this.Segments[i]
+		seg.Begin += offset
+		seg.End += offset
+		for j := range seg.Tokens {
+			seg.Tokens[j].Begin += offset
+			seg.Tokens[j].End += offset
+		}
+	}
+}
+
+// UnmarshalJSON rejects transcripts with negative or reversed times
+func (this *Transcript) UnmarshalJSON(data []byte) error {
+	type transcript Transcript
+	var decoded transcript
+
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	for i := range decoded.Segments {
+		seg := &decoded.Segments[i]
+		if seg.Begin < 0 || seg.End < seg.Begin {
+			return fmt.Errorf("transcript segment %d has invalid times %v - %v", i, seg.Begin, seg.End)
+		}
+		for j := range seg.Tokens {
+			tok := &seg.Tokens[j]
+			if tok.Begin < 0 || tok.End < tok.Begin {
+				return fmt.Errorf("transcript segment %d token %d has invalid times %v - %v", i, j, tok.Begin, tok.End)
+			}
+		}
+	}
+
+	*this = Transcript(decoded)
+	return nil
+}
+
+// Token text is not always valid UTF-8 (see SToken), and encoding/json would replace the invalid bytes.
+// Such tokens are serialised as base64 "bytes" instead of "text" so the round trip is lossless.
+type tokenJSON struct {
+	Text  *string `json:"text,omitempty"`
+	Bytes []byte  `json:"bytes,omitempty"`
+
+	Begin                time.Duration `json:"begin"`
+	End                  time.Duration `json:"end"`
+	Probability          float32       `json:"p"`
+	ProbabilityTimestamp float32       `json:"pt"`
+	Ptsum                float32       `json:"ptsum"`
+	Vlen                 float32       `json:"vlen"`
+	Id                   int32         `json:"id"`
+	Flags                eTokenFlags   `json:"flags,omitempty"`
+}
+
+func (this Token) MarshalJSON() ([]byte, error) {
+	tj := tokenJSON{
+		Begin:                this.Begin,
+		End:                  this.End,
+		Probability:          this.Probability,
+		ProbabilityTimestamp: this.ProbabilityTimestamp,
+		Ptsum:                this.Ptsum,
+		Vlen:                 this.Vlen,
+		Id:                   this.Id,
+		Flags:                this.Flags,
+	}
+
+	if utf8.ValidString(this.Text) {
+		tj.Text = &this.Text
+	} else {
+		tj.Bytes = []byte(this.Text)
+	}
+
+	return json.Marshal(&tj)
+}
+
+func (this *Token) UnmarshalJSON(data []byte) error {
+	var tj tokenJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return err
+	}
+
+	if tj.Text != nil && tj.Bytes != nil {
+		return errors.New("token has both text and bytes")
+	}
+
+	*this = Token{
+		Begin:                tj.Begin,
+		End:                  tj.End,
+		Probability:          tj.Probability,
+		ProbabilityTimestamp: tj.ProbabilityTimestamp,
+		Ptsum:                tj.Ptsum,
+		Vlen:                 tj.Vlen,
+		Id:                   tj.Id,
+		Flags:                tj.Flags,
+	}
+
+	if tj.Text != nil {
+		this.Text = *tj.Text
+	} else {
+		this.Text = string(tj.Bytes)
+	}
+
+	return nil
+}
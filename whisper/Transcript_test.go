@@ -0,0 +1,127 @@
+package whisper
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTranscript() *Transcript {
+	return &Transcript{Segments: []Segment{
+		{Text: " Hello world.", Begin: 0, End: 2 * time.Second, Tokens: []Token{
+			{Text: "[_BEG_]", Flags: TfSpecial, Id: 50364},
+			{Text: " Hello", Begin: 0, End: time.Second, Probability: 0.9, Id: 2425},
+			{Text: " world.", Begin: time.Second, End: 2 * time.Second, Probability: 0.75, Ptsum: 0.5, Id: 1002},
+		}},
+		// The first half of a code point, which isn't valid UTF-8 on its own
+		{Text: " 你", Begin: 2 * time.Second, End: 3 * time.Second, Tokens: []Token{
+			{Text: " \xE4\xBD", Begin: 2 * time.Second, End: 2500 * time.Millisecond, Vlen: 1.5, Id: 7},
+			{Text: "\xA0", Begin: 2500 * time.Millisecond, End: 3 * time.Second, ProbabilityTimestamp: 0.25, Id: 8},
+		}},
+		{Text: "", Begin: 3 * time.Second, End: 3 * time.Second},
+	}}
+}
+
+func TestTranscriptJSON(t *testing.T) {
+	transcript := testTranscript()
+
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// base64 of " \xE4\xBD"
+	if !strings.Contains(string(data), `"bytes":"IOS9"`) {
+		t.Fatalf("the invalid token text was not written as bytes: %s", data)
+	}
+	if !strings.Contains(string(data), `"text":" Hello"`) {
+		t.Fatalf("valid token text was not written as text: %s", data)
+	}
+
+	var decoded Transcript
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, transcript) {
+		t.Fatalf("round trip changed the transcript\n%+v\n%+v", decoded, *transcript)
+	}
+}
+
+func TestTranscriptJSONInvalid(t *testing.T) {
+	cases := map[string]string{
+		"reversed segment": `{"segments":[{"text":"a","begin":2,"end":1}]}`,
+		"negative segment": `{"segments":[{"text":"a","begin":-1,"end":1}]}`,
+		"reversed token":   `{"segments":[{"text":"a","begin":0,"end":5,"tokens":[{"text":"a","begin":3,"end":2}]}]}`,
+		"text and bytes":   `{"segments":[{"text":"a","begin":0,"end":5,"tokens":[{"text":"a","bytes":"YQ==","begin":0,"end":1}]}]}`,
+	}
+	for name, data := range cases {
+		var decoded Transcript
+		if err := json.Unmarshal([]byte(data), &decoded); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+func TestTranscriptIteration(t *testing.T) {
+	transcript := testTranscript()
+
+	var segments []int
+	transcript.ForEachSegment(func(index int, seg *Segment) bool {
+		segments = append(segments, index)
+		return true
+	})
+	if !reflect.DeepEqual(segments, []int{0, 1, 2}) {
+		t.Fatalf("segments %v", segments)
+	}
+
+	segments = nil
+	transcript.ForEachSegment(func(index int, seg *Segment) bool {
+		segments = append(segments, index)
+		return index < 1
+	})
+	if !reflect.DeepEqual(segments, []int{0, 1}) {
+		t.Fatalf("segments %v after stopping at 1", segments)
+	}
+
+	var ids []int32
+	transcript.ForEachToken(func(segment int, tok *Token) bool {
+		ids = append(ids, tok.Id)
+		return true
+	})
+	if !reflect.DeepEqual(ids, []int32{50364, 2425, 1002, 7, 8}) {
+		t.Fatalf("token ids %v", ids)
+	}
+
+	// Stopping within the first segment doesn't continue with the next one
+	ids = nil
+	transcript.ForEachToken(func(segment int, tok *Token) bool {
+		ids = append(ids, tok.Id)
+		return tok.Id != 2425
+	})
+	if !reflect.DeepEqual(ids, []int32{50364, 2425}) {
+		t.Fatalf("token ids %v after stopping at 2425", ids)
+	}
+}
+
+func TestTranscriptSummary(t *testing.T) {
+	transcript := testTranscript()
+	if transcript.CountTokens() != 5 {
+		t.Errorf("%d tokens", transcript.CountTokens())
+	}
+	if transcript.Duration() != 3*time.Second {
+		t.Errorf("duration %v", transcript.Duration())
+	}
+	if transcript.Text() != "Hello world. 你" {
+		t.Errorf("text %q", transcript.Text())
+	}
+	if (&Transcript{}).Duration() != 0 {
+		t.Error("an empty transcript has a duration")
+	}
+
+	transcript.Shift(time.Minute)
+	seg := transcript.Segments[1]
+	if seg.Begin != time.Minute+2*time.Second || seg.Tokens[1].End != time.Minute+3*time.Second {
+		t.Errorf("shifted %+v", seg)
+	}
+}
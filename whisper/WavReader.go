@@ -0,0 +1,435 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper/resample"
+)
+
+// http://soundfile.sapp.org/doc/WaveFormat/
+// https://learn.microsoft.com/en-us/windows/win32/api/mmreg/ns-mmreg-waveformatextensible
+
+// SampleRate of the PCM the model consumes, SAMPLE_RATE in the C++ code
+const SampleRate = 16000
+
+// ErrInvalidWav is wrapped by every error about malformed WAV data
+var ErrInvalidWav = errors.New("invalid WAV data")
+
+const (
+	WavFormatPCM        = 1
+	WavFormatIEEEFloat  = 3
+	wavFormatExtensible = 0xFFFE
+
+	// The data chunk size written by encoders which can't seek back, e.g. ffmpeg writing to a pipe
+	wavSizeUnknown = 0xFFFFFFFF
+
+	// Frames decoded per read from the source
+	wavBlockFrames = 4096
+)
+
+// KSDATAFORMAT_SUBTYPE_PCM and KSDATAFORMAT_SUBTYPE_IEEE_FLOAT share everything but the first 2 bytes
+var wavSubFormatSuffix = [14]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+type WavFormat struct {
+	// WavFormatPCM or WavFormatIEEEFloat, WAVE_FORMAT_EXTENSIBLE is resolved to its sub format
+	FormatTag     uint16
+	Channels      uint16
+	SampleRate    uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	ChannelMask   uint32
+}
+
+func (this *WavFormat) validate() error {
+	switch {
+	case this.FormatTag == WavFormatPCM && (this.BitsPerSample == 8 || this.BitsPerSample == 16 || this.BitsPerSample == 24 || this.BitsPerSample == 32):
+	case this.FormatTag == WavFormatIEEEFloat && (this.BitsPerSample == 32 || this.BitsPerSample == 64):
+	default:
+		return fmt.Errorf("%w: unsupported format 0x%X with %d bits per sample", ErrInvalidWav, this.FormatTag, this.BitsPerSample)
+	}
+
+	if this.Channels == 0 {
+		return fmt.Errorf("%w: no channels", ErrInvalidWav)
+	}
+	if this.SampleRate == 0 {
+		return fmt.Errorf("%w: sample rate is 0", ErrInvalidWav)
+	}
+	if int(this.BlockAlign) != int(this.Channels)*int(this.BitsPerSample/8) {
+		return fmt.Errorf("%w: block align %d doesn't match %d channels of %d bits", ErrInvalidWav, this.BlockAlign, this.Channels, this.BitsPerSample)
+	}
+	return nil
+}
+
+func (this *WavFormat) IsFloat() bool {
+	return this.FormatTag == WavFormatIEEEFloat
+}
+
+// PcmBuffer is audio in the format of iAudioBuffer: 16 kHz mono, and optionally interleaved stereo of the same length
+type PcmBuffer struct {
+	Mono   []float32
+	Stereo []float32
+}
+
+// Duration of the audio
+func (this *PcmBuffer) Duration() time.Duration {
+	return time.Duration(len(this.Mono)) * time.Second / SampleRate
+}
+
+// WavReader streams a WAV file as 16 kHz float PCM, with the semantics of iAudioBuffer.getPcmMono and getPcmStereo:
+// mono is the average of all channels, stereo is the first two channels, or the mono channel twice.
+// Only one block of the source is held in memory.
+type WavReader struct {
+	Format WavFormat
+
+	r io.Reader
+
+	// Bytes of the data chunk not read yet, -1 to read until EOF
+	remaining int64
+
+	// Source frames of the data chunk, -1 when unknown
+	frames int64
+
+	raw       []byte
+	resampler *resample.Resampler
+
+	// Resampled frames not returned yet, interleaved mono, left, right; pending is the tail of resampled
+	resampled []float32
+	pending   []float32
+	eof       bool
+}
+
+// NewWavReader reads the RIFF header and the chunks up to the start of the audio data
+func NewWavReader(r io.Reader) (*WavReader, error) {
+	this := &WavReader{r: r}
+
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, wavReadError("RIFF header", err)
+	}
+	if string(header[0:4]) == "RF64" {
+		return nil, fmt.Errorf("%w: RF64 files are not supported", ErrInvalidWav)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a RIFF WAVE file", ErrInvalidWav)
+	}
+
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: no data chunk", ErrInvalidWav)
+			}
+			return nil, wavReadError("chunk header", err)
+		}
+
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if err := this.readFormat(size); err != nil {
+				return nil, err
+			}
+			haveFormat = true
+
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("%w: data chunk before the fmt chunk", ErrInvalidWav)
+			}
+			if err := this.start(size); err != nil {
+				return nil, err
+			}
+			return this, nil
+
+		default:
+			// LIST, fact, cue and so on, padded to an even size
+			skip := int64(size) + int64(size&1)
+			if _, err := io.CopyN(io.Discard, r, skip); err != nil {
+				return nil, wavReadError(fmt.Sprintf("%q chunk", id), err)
+			}
+		}
+	}
+}
+
+func (this *WavReader) readFormat(size uint32) error {
+	if size < 16 {
+		return fmt.Errorf("%w: fmt chunk is %d bytes", ErrInvalidWav, size)
+	}
+	if size > 1024 {
+		return fmt.Errorf("%w: fmt chunk is %d bytes", ErrInvalidWav, size)
+	}
+
+	data := make([]byte, int(size)+int(size&1))
+	if _, err := io.ReadFull(this.r, data); err != nil {
+		return wavReadError("fmt chunk", err)
+	}
+
+	f := WavFormat{
+		FormatTag:     binary.LittleEndian.Uint16(data[0:2]),
+		Channels:      binary.LittleEndian.Uint16(data[2:4]),
+		SampleRate:    binary.LittleEndian.Uint32(data[4:8]),
+		BlockAlign:    binary.LittleEndian.Uint16(data[12:14]),
+		BitsPerSample: binary.LittleEndian.Uint16(data[14:16]),
+	}
+
+	if f.FormatTag == wavFormatExtensible {
+		if size < 40 {
+			return fmt.Errorf("%w: WAVE_FORMAT_EXTENSIBLE fmt chunk is %d bytes", ErrInvalidWav, size)
+		}
+		validBits := binary.LittleEndian.Uint16(data[18:20])
+		f.ChannelMask = binary.LittleEndian.Uint32(data[20:24])
+		if [14]byte(data[26:40]) != wavSubFormatSuffix {
+			return fmt.Errorf("%w: unsupported WAVE_FORMAT_EXTENSIBLE sub format", ErrInvalidWav)
+		}
+		f.FormatTag = binary.LittleEndian.Uint16(data[24:26])
+		if validBits != 0 && validBits > f.BitsPerSample {
+			return fmt.Errorf("%w: %d valid bits in %d bit samples", ErrInvalidWav, validBits, f.BitsPerSample)
+		}
+	}
+
+	if err := f.validate(); err != nil {
+		return err
+	}
+
+	this.Format = f
+	return nil
+}
+
+func (this *WavReader) start(dataSize uint32) error {
+	if dataSize == wavSizeUnknown {
+		this.remaining = -1
+		this.frames = -1
+	} else {
+		this.remaining = int64(dataSize)
+		this.frames = int64(dataSize) / int64(this.Format.BlockAlign)
+	}
+
+	this.raw = make([]byte, wavBlockFrames*int(this.Format.BlockAlign))
+	// Mono, left and right are resampled together
+	resampler, err := resample.New(int(this.Format.SampleRate), SampleRate, 3, resample.High)
+	if err != nil {
+		return err
+	}
+	this.resampler = resampler
+	return nil
+}
+
+// NewRawPcmReader reads headerless PCM of the given format until EOF, e.g. the output of ffmpeg -f f32le.
+// Only FormatTag, Channels, SampleRate and BitsPerSample need to be set.
+func NewRawPcmReader(r io.Reader, format WavFormat) (*WavReader, error) {
+	format.BlockAlign = format.Channels * (format.BitsPerSample / 8)
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+
+	this := &WavReader{r: r, Format: format}
+	if err := this.start(wavSizeUnknown); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+// Duration of the audio, 0 when the data chunk size is unknown
+func (this *WavReader) Duration() time.Duration {
+	if this.frames < 0 {
+		return 0
+	}
+	return time.Duration(this.frames) * time.Second / time.Duration(this.Format.SampleRate)
+}
+
+// ReadMono reads up to len(mono) samples of 16 kHz mono PCM
+func (this *WavReader) ReadMono(mono []float32) (int, error) {
+	return this.ReadPcm(mono, nil)
+}
+
+// ReadPcm reads up to len(mono) samples of 16 kHz PCM.
+// When stereo is not nil it must be 2*len(mono) long, and receives the same samples as interleaved stereo.
+// Returns io.EOF after the last sample.
+func (this *WavReader) ReadPcm(mono []float32, stereo []float32) (int, error) {
+	if stereo != nil && len(stereo) != 2*len(mono) {
+		return 0, fmt.Errorf("ReadPcm: stereo buffer is %d samples, expected %d", len(stereo), 2*len(mono))
+	}
+
+	n := 0
+	for n < len(mono) {
+		if len(this.pending) == 0 {
+			if this.eof {
+				break
+			}
+			if err := this.decodeBlock(); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		count := len(this.pending) / 3
+		if count > len(mono)-n {
+			count = len(mono) - n
+		}
+		for i := 0; i < count; i++ {
+			mono[n+i] = this.pending[i*3]
+			if stereo != nil {
+				stereo[(n+i)*2] = this.pending[i*3+1]
+				stereo[(n+i)*2+1] = this.pending[i*3+2]
+			}
+		}
+		this.pending = this.pending[count*3:]
+		n += count
+	}
+
+	if n == 0 && len(mono) > 0 && this.eof {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// decodeBlock reads one block of the data chunk into pending
+func (this *WavReader) decodeBlock() error {
+	want := int64(len(this.raw))
+	if this.remaining >= 0 && this.remaining < want {
+		want = this.remaining
+	}
+
+	var n int
+	var err error
+	if want > 0 {
+		n, err = io.ReadFull(this.r, this.raw[:want])
+	}
+
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		if this.remaining >= 0 {
+			return fmt.Errorf("%w: data chunk is truncated", ErrInvalidWav)
+		}
+		if n%int(this.Format.BlockAlign) != 0 {
+			return fmt.Errorf("%w: data ends within a frame", ErrInvalidWav)
+		}
+		this.eof = true
+	case err != nil:
+		return err
+	}
+
+	if this.remaining >= 0 {
+		this.remaining -= int64(n)
+		if this.remaining < int64(this.Format.BlockAlign) {
+			// An odd trailing byte is padding, not a frame
+			this.eof = true
+		}
+	}
+
+	frames := this.mixFrames(this.raw[:n])
+	resampled, err := this.resampler.Process(this.resampled[:0], frames)
+	if err != nil {
+		return err
+	}
+	if this.eof {
+		resampled = this.resampler.Flush(resampled)
+	}
+	this.resampled = resampled
+	this.pending = resampled
+	return nil
+}
+
+// mixFrames converts the source frames to interleaved mono, left, right
+func (this *WavReader) mixFrames(data []byte) []float32 {
+	f := &this.Format
+	channels := int(f.Channels)
+	bytesPerSample := int(f.BitsPerSample / 8)
+	frames := len(data) / int(f.BlockAlign)
+
+	out := make([]float32, frames*3)
+	for i := 0; i < frames; i++ {
+		frame := data[i*int(f.BlockAlign):]
+
+		var sum float32
+		var left, right float32
+		for c := 0; c < channels; c++ {
+			sample := decodeSample(frame[c*bytesPerSample:], f)
+			sum += sample
+			if c == 0 {
+				left = sample
+				right = sample
+			} else if c == 1 {
+				right = sample
+			}
+		}
+
+		out[i*3] = sum / float32(channels)
+		out[i*3+1] = left
+		out[i*3+2] = right
+	}
+	return out
+}
+
+func decodeSample(b []byte, f *WavFormat) float32 {
+	if f.IsFloat() {
+		if f.BitsPerSample == 64 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	switch f.BitsPerSample {
+	case 8:
+		// The only unsigned format
+		return float32(int(b[0])-128) / 128
+	case 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 8388608
+	default:
+		return float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648)
+	}
+}
+
+func wavReadError(what string, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %s is truncated", ErrInvalidWav, what)
+	}
+	return err
+}
+
+// DecodeWav reads the whole WAV stream as 16 kHz PCM, Stereo is only filled when stereo is true
+func DecodeWav(r io.Reader, stereo bool) (*PcmBuffer, error) {
+	reader, err := NewWavReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PcmBuffer{}
+	if reader.frames > 0 {
+		capacity := reader.frames*SampleRate/int64(reader.Format.SampleRate) + 1
+		result.Mono = make([]float32, 0, capacity)
+		if stereo {
+			result.Stereo = make([]float32, 0, capacity*2)
+		}
+	}
+
+	mono := make([]float32, wavBlockFrames)
+	var pair []float32
+	if stereo {
+		pair = make([]float32, wavBlockFrames*2)
+	}
+
+	for {
+		n, err := reader.ReadPcm(mono, pair)
+		result.Mono = append(result.Mono, mono[:n]...)
+		if stereo {
+			result.Stereo = append(result.Stereo, pair[:n*2]...)
+		}
+
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package whisper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// testWav describes a WAV file built in memory
+type testWav struct {
+	tag        uint16
+	channels   uint16
+	rate       uint32
+	bits       uint16
+	extensible bool
+
+	// A chunk of this size before the data chunk, odd sizes are padded
+	extraChunk int
+	// Written as the size of the data chunk, with wavSizeUnknown for example
+	dataSize *uint32
+	// Bytes after the data chunk, e.g. its padding
+	trailer []byte
+}
+
+func (this testWav) encode(frames [][]float64) []byte {
+	var data []byte
+	for _, frame := range frames {
+		for _, s := range frame {
+			data = append(data, encodeSample(s, this.tag, this.bits)...)
+		}
+	}
+	return this.build(data)
+}
+
+func (this testWav) build(data []byte) []byte {
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	chunk := func(id string, body []byte) {
+		buf.WriteString(id)
+		binary.Write(&buf, le, uint32(len(body)))
+		buf.Write(body)
+		if len(body)%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+
+	fmtChunk := make([]byte, 16)
+	tag := this.tag
+	if this.extensible {
+		tag = wavFormatExtensible
+		fmtChunk = make([]byte, 40)
+		le.PutUint16(fmtChunk[16:], 22)
+		le.PutUint16(fmtChunk[18:], this.bits)
+		le.PutUint32(fmtChunk[20:], 3)
+		le.PutUint16(fmtChunk[24:], this.tag)
+		copy(fmtChunk[26:], wavSubFormatSuffix[:])
+	}
+	le.PutUint16(fmtChunk[0:], tag)
+	le.PutUint16(fmtChunk[2:], this.channels)
+	le.PutUint32(fmtChunk[4:], this.rate)
+	le.PutUint32(fmtChunk[8:], this.rate*uint32(this.channels)*uint32(this.bits/8))
+	le.PutUint16(fmtChunk[12:], this.channels*(this.bits/8))
+	le.PutUint16(fmtChunk[14:], this.bits)
+
+	buf.WriteString("RIFF")
+	binary.Write(&buf, le, uint32(0))
+	buf.WriteString("WAVE")
+	chunk("fmt ", fmtChunk)
+	if this.extraChunk > 0 {
+		chunk("LIST", bytes.Repeat([]byte{'x'}, this.extraChunk))
+	}
+
+	buf.WriteString("data")
+	size := uint32(len(data))
+	if this.dataSize != nil {
+		size = *this.dataSize
+	}
+	binary.Write(&buf, le, size)
+	buf.Write(data)
+	buf.Write(this.trailer)
+	return buf.Bytes()
+}
+
+func encodeSample(s float64, tag uint16, bits uint16) []byte {
+	b := make([]byte, bits/8)
+	le := binary.LittleEndian
+	switch {
+	case tag == WavFormatIEEEFloat && bits == 32:
+		le.PutUint32(b, math.Float32bits(float32(s)))
+	case tag == WavFormatIEEEFloat:
+		le.PutUint64(b, math.Float64bits(s))
+	case bits == 8:
+		b[0] = byte(int(math.Round(s*128)) + 128)
+	case bits == 16:
+		le.PutUint16(b, uint16(int16(math.Round(s*32768))))
+	case bits == 24:
+		v := uint32(int32(math.Round(s * 8388608)))
+		b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+	default:
+		le.PutUint32(b, uint32(int32(math.Round(s*2147483648))))
+	}
+	return b
+}
+
+func monoFrames(samples ...float64) [][]float64 {
+	frames := make([][]float64, len(samples))
+	for i, s := range samples {
+		frames[i] = []float64{s}
+	}
+	return frames
+}
+
+func checkSamples(t *testing.T, what string, got []float32, expected []float64, tolerance float64) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("%s: %d samples, expected %d", what, len(got), len(expected))
+	}
+	for i := range got {
+		if math.Abs(float64(got[i])-expected[i]) > tolerance {
+			t.Fatalf("%s: sample %d is %v, expected %v", what, i, got[i], expected[i])
+		}
+	}
+}
+
+func TestDecodeWavFormats(t *testing.T) {
+	samples := []float64{0, 0.5, -0.5, -1, 0.25}
+	cases := []struct {
+		name       string
+		tag        uint16
+		bits       uint16
+		extensible bool
+		tolerance  float64
+	}{
+		{"pcm8", WavFormatPCM, 8, false, 1.0 / 128},
+		{"pcm16", WavFormatPCM, 16, false, 1.0 / 32768},
+		{"pcm24", WavFormatPCM, 24, false, 1.0 / 8388608},
+		{"pcm32", WavFormatPCM, 32, false, 1e-7},
+		{"float32", WavFormatIEEEFloat, 32, false, 0},
+		{"float64", WavFormatIEEEFloat, 64, false, 0},
+		{"extensible pcm16", WavFormatPCM, 16, true, 1.0 / 32768},
+		{"extensible float32", WavFormatIEEEFloat, 32, true, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := testWav{tag: c.tag, channels: 1, rate: SampleRate, bits: c.bits, extensible: c.extensible}
+			reader, err := NewWavReader(bytes.NewReader(w.encode(monoFrames(samples...))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reader.Format.FormatTag != c.tag || reader.Format.BitsPerSample != c.bits {
+				t.Fatalf("format %+v", reader.Format)
+			}
+			if d := reader.Duration(); d != time.Duration(len(samples))*time.Second/SampleRate {
+				t.Fatalf("duration %v", d)
+			}
+
+			pcm, err := DecodeWav(bytes.NewReader(w.encode(monoFrames(samples...))), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSamples(t, "mono", pcm.Mono, samples, c.tolerance)
+			if pcm.Stereo != nil {
+				t.Fatal("stereo was not requested")
+			}
+		})
+	}
+}
+
+func TestDecodeWavStereo(t *testing.T) {
+	w := testWav{tag: WavFormatIEEEFloat, channels: 2, rate: SampleRate, bits: 32}
+	frames := [][]float64{{0.5, -0.25}, {1, 0}, {-1, -0.5}}
+
+	pcm, err := DecodeWav(bytes.NewReader(w.encode(frames)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "mono", pcm.Mono, []float64{0.125, 0.5, -0.75}, 0)
+	checkSamples(t, "stereo", pcm.Stereo, []float64{0.5, -0.25, 1, 0, -1, -0.5}, 0)
+
+	// Mono audio is both channels of the stereo
+	w.channels = 1
+	pcm, err = DecodeWav(bytes.NewReader(w.encode(monoFrames(0.5, -0.25))), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "stereo of mono", pcm.Stereo, []float64{0.5, 0.5, -0.25, -0.25}, 0)
+
+	// Channels past the first two only count for the mono mix
+	w.channels = 3
+	pcm, err = DecodeWav(bytes.NewReader(w.encode([][]float64{{0.25, 0.5, 0.75}})), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "mono of 3 channels", pcm.Mono, []float64{0.5}, 1e-7)
+	checkSamples(t, "stereo of 3 channels", pcm.Stereo, []float64{0.25, 0.5}, 0)
+}
+
+func TestDecodeWavPadding(t *testing.T) {
+	// An odd sized chunk before the data, and 3 bytes of 8 bit audio followed by their pad byte
+	w := testWav{tag: WavFormatPCM, channels: 1, rate: SampleRate, bits: 8, extraChunk: 5}
+	pcm, err := DecodeWav(bytes.NewReader(w.encode(monoFrames(0.5, 0, -0.5))), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "mono", pcm.Mono, []float64{0.5, 0, -0.5}, 0)
+}
+
+func TestDecodeWavUnknownSize(t *testing.T) {
+	unknown := uint32(wavSizeUnknown)
+	w := testWav{tag: WavFormatPCM, channels: 1, rate: SampleRate, bits: 16, dataSize: &unknown}
+	data := w.encode(monoFrames(0.5, -0.5, 0.25))
+
+	reader, err := NewWavReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Duration() != 0 {
+		t.Fatalf("duration %v of an unknown size", reader.Duration())
+	}
+
+	pcm, err := DecodeWav(bytes.NewReader(data), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "mono", pcm.Mono, []float64{0.5, -0.5, 0.25}, 1.0/32768)
+
+	// Until EOF, which must not fall within a frame
+	if _, err := DecodeWav(bytes.NewReader(data[:len(data)-1]), false); !errors.Is(err, ErrInvalidWav) {
+		t.Fatalf("a partial frame returned %v", err)
+	}
+}
+
+func TestDecodeWavResamples(t *testing.T) {
+	const rate = 44100
+	const freq = 440.0
+	samples := make([]float64, rate)
+	for i := range samples {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*freq*float64(i)/rate)
+	}
+
+	w := testWav{tag: WavFormatIEEEFloat, channels: 1, rate: rate, bits: 32}
+	pcm, err := DecodeWav(bytes.NewReader(w.encode(monoFrames(samples...))), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm.Mono) != SampleRate || len(pcm.Stereo) != 2*SampleRate {
+		t.Fatalf("%d mono and %d stereo samples from 1 second", len(pcm.Mono), len(pcm.Stereo))
+	}
+
+	// Away from the edges, where the filter reads past the audio
+	for i := 100; i < len(pcm.Mono)-100; i++ {
+		expected := 0.5 * math.Sin(2*math.Pi*freq*float64(i)/SampleRate)
+		if math.Abs(float64(pcm.Mono[i])-expected) > 1e-3 {
+			t.Fatalf("sample %d is %v, expected %v", i, pcm.Mono[i], expected)
+		}
+		if pcm.Stereo[2*i] != pcm.Mono[i] || pcm.Stereo[2*i+1] != pcm.Mono[i] {
+			t.Fatalf("stereo sample %d differs from mono", i)
+		}
+	}
+}
+
+func TestDecodeWavErrors(t *testing.T) {
+	good := testWav{tag: WavFormatPCM, channels: 1, rate: SampleRate, bits: 16}
+	valid := good.encode(monoFrames(0.5, 0.5, 0.5))
+
+	rf64 := append([]byte("RF64"), valid[4:]...)
+	notWave := append(append([]byte(nil), valid[:8]...), "AVI "...)
+	// RIFF, and a data chunk without a fmt chunk
+	noFmt := append(append([]byte(nil), valid[:12]...), valid[12+8+16:]...)
+	// The fmt chunk, and nothing else
+	noData := valid[:12+8+16]
+
+	adpcm := good
+	adpcm.tag = 2
+	bits12 := good
+	bits12.bits = 12
+	noChannels := good
+	noChannels.channels = 0
+	noRate := good
+	noRate.rate = 0
+	badBlockAlign := good.encode(monoFrames(0.5))
+	binary.LittleEndian.PutUint16(badBlockAlign[12+8+12:], 3)
+	badSubFormat := testWav{tag: WavFormatPCM, channels: 1, rate: SampleRate, bits: 16, extensible: true}.encode(monoFrames(0.5))
+	badSubFormat[12+8+30] = 0xFF
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", valid[:10]},
+		{"RF64", rf64},
+		{"not WAVE", notWave},
+		{"data before fmt", noFmt},
+		{"no data chunk", noData},
+		{"truncated fmt chunk", valid[:12+8+10]},
+		{"truncated data", valid[:len(valid)-2]},
+		{"ADPCM", adpcm.encode(nil)},
+		{"12 bits", bits12.build(make([]byte, 4))},
+		{"no channels", noChannels.encode(nil)},
+		{"sample rate 0", noRate.encode(nil)},
+		{"block align", badBlockAlign},
+		{"extensible sub format", badSubFormat},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := DecodeWav(bytes.NewReader(c.data), true)
+			if !errors.Is(err, ErrInvalidWav) {
+				t.Fatalf("returned %v, expected ErrInvalidWav", err)
+			}
+		})
+	}
+}
+
+func TestRawPcmReader(t *testing.T) {
+	var data []byte
+	for _, s := range []float64{0.5, -0.5, 0.25, 1} {
+		data = append(data, encodeSample(s, WavFormatIEEEFloat, 32)...)
+	}
+
+	reader, err := NewRawPcmReader(bytes.NewReader(data), WavFormat{FormatTag: WavFormatIEEEFloat, Channels: 2, SampleRate: SampleRate, BitsPerSample: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mono := make([]float32, 4)
+	stereo := make([]float32, 8)
+	n, err := reader.ReadPcm(mono, stereo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSamples(t, "mono", mono[:n], []float64{0, 0.625}, 0)
+	checkSamples(t, "stereo", stereo[:2*n], []float64{0.5, -0.5, 0.25, 1}, 0)
+
+	if _, err := reader.ReadPcm(mono, stereo[:3]); err == nil {
+		t.Fatal("a stereo buffer of the wrong length was accepted")
+	}
+	if n, err := reader.ReadPcm(mono, stereo); n != 0 || err == nil {
+		t.Fatalf("read %d, %v after the end", n, err)
+	}
+}
@@ -0,0 +1,211 @@
+package whisper
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Word is a run of sub-word tokens, as produced by FlagTokenTimestamps.
+// e.g. the tokens " trans", "cribe", "d" become the word "transcribed"
+type Word struct {
+	Text  string
+	Begin time.Duration
+	End   time.Duration
+
+	// Probabilities of the tokens the word was built from
+	MinProbability  float32
+	MeanProbability float32
+
+	// Ids of the tokens the word was built from, and their indices in Segment.Tokens
+	TokenIds     []int32
+	TokenIndices []int
+
+	// Index of the segment in the transcript, always 0 for Segment.Words
+	Segment int
+}
+
+// Words merges the non special tokens of the segment into words.
+// Words are split on whitespace, trailing punctuation is attached to the previous word,
+// and every Chinese or Japanese character is a word on its own since these languages don't use spaces.
+// Code points split between tokens are joined first, see RepairTokens.
+// Returns nil when the segment has no tokens.
+func (this *Segment) Words() []Word {
+	tokens, _, merged := repairTokens(this.Tokens)
+	wb := wordBuilder{tokens: tokens, merged: merged}
+
+	for i := range tokens {
+		tok := &tokens[i]
+		if tok.IsSpecial() {
+			wb.flush()
+			continue
+		}
+		wb.addToken(i)
+	}
+	wb.flush()
+
+	return wb.words
+}
+
+// Words returns the words of every segment, see Segment.Words
+func (this *Transcript) Words() []Word {
+	var words []Word
+	for i := range this.Segments {
+		segWords := this.Segments[i].Words()
+		for j := range segWords {
+			segWords[j].Segment = i
+		}
+		words = append(words, segWords...)
+	}
+	return words
+}
+
+type wordBuilder struct {
+	// The repaired tokens of the segment, and the last token merged into each of them
+	tokens []Token
+	merged []int
+
+	words []Word
+
+	// The word being built, text is empty when there is none
+	text       strings.Builder
+	begin, end time.Duration
+	indices    []int
+}
+
+func (this *wordBuilder) addToken(index int) {
+	tok := &this.tokens[index]
+	text := tok.Text
+
+	// Whitespace around the token doesn't take any time
+	lead := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	content := len(strings.TrimRightFunc(text, unicode.IsSpace)) - lead
+
+	for offset := 0; offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		begin, end := runeTimes(tok, offset-lead, size, content)
+		runeText := text[offset : offset+size]
+		offset += size
+
+		switch {
+		case r != utf8.RuneError && unicode.IsSpace(r):
+			this.flush()
+
+		case isClosingPunct(r):
+			if this.text.Len() == 0 && len(this.words) > 0 {
+				this.extendLast(index, runeText, end)
+			} else {
+				this.append(index, runeText, begin, end)
+			}
+
+		case isWordCharacter(r):
+			this.flush()
+			this.append(index, runeText, begin, end)
+			this.flush()
+
+		default:
+			// Letters, digits, and other punctuation
+			this.append(index, runeText, begin, end)
+		}
+	}
+}
+
+func (this *wordBuilder) append(index int, text string, begin, end time.Duration) {
+	if this.text.Len() == 0 {
+		this.begin = begin
+	}
+	this.text.WriteString(text)
+	this.end = end
+	this.indices = this.addIndices(this.indices, index)
+}
+
+// addIndices appends the token, and the tokens which contributed bytes to it, unless already present
+func (this *wordBuilder) addIndices(indices []int, index int) []int {
+	for i := index; i <= this.merged[index]; i++ {
+		if n := len(indices); n > 0 && indices[n-1] >= i {
+			continue
+		}
+		if this.tokens[i].IsSpecial() {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// extendLast attaches punctuation which doesn't continue a word, e.g. " ?" in French, to the previous word
+func (this *wordBuilder) extendLast(index int, text string, end time.Duration) {
+	last := &this.words[len(this.words)-1]
+	last.Text += text
+	last.End = end
+	this.setTokens(last, this.addIndices(last.TokenIndices, index))
+}
+
+func (this *wordBuilder) flush() {
+	if this.text.Len() == 0 {
+		return
+	}
+
+	word := Word{
+		Text:  this.text.String(),
+		Begin: this.begin,
+		End:   this.end,
+	}
+	this.setTokens(&word, this.indices)
+
+	this.words = append(this.words, word)
+	this.text.Reset()
+	this.indices = nil
+}
+
+// setTokens sets the token indices of the word, and the ids and probabilities of these tokens
+func (this *wordBuilder) setTokens(word *Word, indices []int) {
+	word.TokenIndices = indices
+	word.TokenIds = make([]int32, len(indices))
+
+	var sum float32
+	for i, index := range indices {
+		tok := &this.tokens[index]
+		word.TokenIds[i] = tok.Id
+		sum += tok.Probability
+		if i == 0 || tok.Probability < word.MinProbability {
+			word.MinProbability = tok.Probability
+		}
+	}
+	word.MeanProbability = sum / float32(len(indices))
+}
+
+// runeTimes interpolates the time span of the bytes [offset, offset+size) of the token content, which is length bytes long
+func runeTimes(tok *Token, offset, size, length int) (time.Duration, time.Duration) {
+	span := tok.End - tok.Begin
+	if span <= 0 || length <= 0 {
+		return tok.Begin, tok.End
+	}
+
+	at := func(pos int) time.Duration {
+		if pos < 0 {
+			pos = 0
+		} else if pos > length {
+			pos = length
+		}
+		return tok.Begin + span*time.Duration(pos)/time.Duration(length)
+	}
+
+	return at(offset), at(offset + size)
+}
+
+// isWordCharacter is true for the scripts written without spaces between words, where every character is a word
+func isWordCharacter(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// isClosingPunct is true for punctuation which belongs to the end of the previous word.
+// Quotes are ambiguous and dashes join words, so neither are included.
+func isClosingPunct(r rune) bool {
+	switch r {
+	case '.', ',', '!', '?', ';', ':', '…', '。', '、', '，', '！', '？', '；', '：':
+		return true
+	}
+	return unicode.In(r, unicode.Pe, unicode.Pf)
+}
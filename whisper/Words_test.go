@@ -0,0 +1,145 @@
+package whisper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestWords(t *testing.T) {
+	type word struct {
+		text       string
+		begin, end time.Duration
+		indices    []int
+	}
+
+	cases := []struct {
+		name     string
+		tokens   []Token
+		expected []word
+	}{
+		{
+			name: "sub-word tokens",
+			tokens: []Token{
+				{Text: " trans", Begin: 0, End: ms(1000)},
+				{Text: "cribe", Begin: ms(1000), End: ms(2000)},
+				{Text: "d", Begin: ms(2000), End: ms(2500)},
+				{Text: " it", Begin: ms(3000), End: ms(4000)},
+			},
+			expected: []word{
+				{"transcribed", 0, ms(2500), []int{0, 1, 2}},
+				{"it", ms(3000), ms(4000), []int{3}},
+			},
+		},
+		{
+			name: "punctuation",
+			tokens: []Token{
+				{Text: " Hello", Begin: 0, End: ms(500)},
+				{Text: ",", Begin: ms(500), End: ms(600)},
+				{Text: " world", Begin: ms(600), End: ms(1000)},
+				{Text: " ?", Begin: ms(1000), End: ms(1200)},
+				{Text: " (yes)", Begin: ms(1200), End: ms(1700)},
+			},
+			expected: []word{
+				{"Hello,", 0, ms(600), []int{0, 1}},
+				{"world?", ms(600), ms(1200), []int{2, 3}},
+				{"(yes)", ms(1200), ms(1700), []int{4}},
+			},
+		},
+		{
+			name: "special tokens",
+			tokens: []Token{
+				{Text: "[_BEG_]", Flags: TfSpecial},
+				{Text: " Hi", Begin: 0, End: ms(400)},
+				{Text: "[_TT_20]", Flags: TfSpecial},
+				{Text: "there", Begin: ms(400), End: ms(800)},
+			},
+			expected: []word{
+				{"Hi", 0, ms(400), []int{1}},
+				{"there", ms(400), ms(800), []int{3}},
+			},
+		},
+		{
+			name: "a word per character",
+			tokens: []Token{
+				{Text: "你好", Begin: 0, End: ms(1000)},
+				{Text: "。", Begin: ms(1000), End: ms(1100)},
+			},
+			expected: []word{
+				{"你", 0, ms(500), []int{0}},
+				{"好。", ms(500), ms(1100), []int{0, 1}},
+			},
+		},
+		{
+			name: "split code point",
+			tokens: []Token{
+				{Text: " \xE4\xBD", Begin: 0, End: ms(1000)},
+				{Text: "\xA0好", Begin: ms(1000), End: ms(2000)},
+			},
+			expected: []word{
+				{"你", 0, ms(2000), []int{0, 1}},
+				{"好", ms(1000), ms(2000), []int{1}},
+			},
+		},
+		{
+			name:     "no tokens",
+			tokens:   nil,
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			seg := Segment{Tokens: c.tokens}
+			var got []word
+			for _, w := range seg.Words() {
+				got = append(got, word{w.Text, w.Begin, w.End, w.TokenIndices})
+			}
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Fatalf("got\n%+v\nexpected\n%+v", got, c.expected)
+			}
+		})
+	}
+}
+
+func TestWordProbabilities(t *testing.T) {
+	seg := Segment{Tokens: []Token{
+		{Text: " un", Probability: 0.5, Id: 10},
+		{Text: "like", Probability: 0.75, Id: 11},
+		{Text: "ly", Probability: 1, Id: 12},
+	}}
+
+	words := seg.Words()
+	if len(words) != 1 {
+		t.Fatalf("%d words", len(words))
+	}
+	w := words[0]
+	if w.Text != "unlikely" || w.MinProbability != 0.5 || w.MeanProbability != 0.75 {
+		t.Fatalf("%+v", w)
+	}
+	if !reflect.DeepEqual(w.TokenIds, []int32{10, 11, 12}) {
+		t.Fatalf("token ids %v", w.TokenIds)
+	}
+}
+
+func TestTranscriptWords(t *testing.T) {
+	transcript := Transcript{Segments: []Segment{
+		{Tokens: []Token{{Text: " one"}}},
+		{},
+		{Tokens: []Token{{Text: " two"}, {Text: " three"}}},
+	}}
+
+	var got []string
+	var segments []int
+	for _, w := range transcript.Words() {
+		got = append(got, w.Text)
+		segments = append(segments, w.Segment)
+	}
+	if !reflect.DeepEqual(got, []string{"one", "two", "three"}) || !reflect.DeepEqual(segments, []int{0, 2, 2}) {
+		t.Fatalf("words %v of segments %v", got, segments)
+	}
+}
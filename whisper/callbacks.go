@@ -0,0 +1,44 @@
+package whisper
+
+import (
+	"sync"
+)
+
+// Callbacks made with syscall.NewCallback are never freed, and only a limited number can be created.
+// So every native callback is created once, and the void* context argument of the native API
+// carries a handle to the Go state of the call instead of a Go pointer.
+
+type callbackHandles struct {
+	mutex  sync.Mutex
+	next   uintptr
+	values map[uintptr]any
+}
+
+var handles = callbackHandles{values: make(map[uintptr]any)}
+
+// add returns a non zero handle for the value, which must be removed once the native code stops using it
+func (this *callbackHandles) add(value any) uintptr {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.next++
+	if this.next == 0 {
+		this.next++
+	}
+	this.values[this.next] = value
+	return this.next
+}
+
+func (this *callbackHandles) get(handle uintptr) any {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.values[handle]
+}
+
+func (this *callbackHandles) remove(handle uintptr) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	delete(this.values, handle)
+}
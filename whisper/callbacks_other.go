@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+// newCallback returns a null function pointer, nothing can call it on this platform
+func newCallback(fn any) uintptr {
+	return 0
+}
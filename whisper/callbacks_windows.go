@@ -0,0 +1,10 @@
+package whisper
+
+import (
+	"syscall"
+)
+
+// newCallback is syscall.NewCallback, for the files which build on every platform
+func newCallback(fn any) uintptr {
+	return syscall.NewCallback(fn)
+}
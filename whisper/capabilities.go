@@ -0,0 +1,71 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Capabilities are what a version of whisper.dll supports. The bindings check them before calling
+// a vtable slot or passing a struct the DLL would not understand.
+type Capabilities struct {
+	// Contexts of one model can run on several threads at once
+	MultiThread bool
+
+	// Models can be loaded with gmf_Cloneable, and cloned by iModel::clone
+	CloneableModels bool
+
+	// iMediaFoundation can list and open capture devices, and iContext::runCapture transcribes them
+	Capture bool
+
+	// The variant of abiLayouts describing sFullParams and the other structs
+	ParamsLayout int
+}
+
+// capabilityTable holds the capabilities from a DLL version until the next entry, oldest first.
+// Versions before the first entry are not supported.
+var capabilityTable = []struct {
+	minVersion   WinVersion
+	capabilities Capabilities
+}{
+	{WinVersion{1, 9, 0, 0}, Capabilities{Capture: true, ParamsLayout: 1}},
+	{WinVersion{1, 10, 0, 0}, Capabilities{MultiThread: true, CloneableModels: true, Capture: true, ParamsLayout: 1}},
+}
+
+// MinimumVersion is the oldest whisper.dll the bindings support
+var MinimumVersion = capabilityTable[0].minVersion
+
+// CapabilitiesFor returns the capabilities of a DLL version, false when the version is older than MinimumVersion
+func CapabilitiesFor(ver WinVersion) (Capabilities, bool) {
+	var found Capabilities
+	ok := false
+	for _, entry := range capabilityTable {
+		if ver.AtLeast(entry.minVersion) {
+			found, ok = entry.capabilities, true
+		}
+	}
+	return found, ok
+}
+
+// ErrNotSupportedByDLL is returned instead of calling whisper.dll when its version lacks a feature.
+// The errors are *FeatureError, which match it with errors.Is
+var ErrNotSupportedByDLL = errors.New("not supported by this version of whisper.dll")
+
+// FeatureError is the error of a call the loaded whisper.dll does not support
+type FeatureError struct {
+	Op      string
+	Feature string
+	Version WinVersion
+}
+
+func (this *FeatureError) Error() string {
+	return fmt.Sprintf("%s: %s is not supported by whisper.dll version %s", this.Op, this.Feature, this.Version)
+}
+
+func (this *FeatureError) Is(target error) bool {
+	return target == ErrNotSupportedByDLL
+}
+
+// Capabilities returns what the loaded whisper.dll supports
+func (this *Libwhisper) Capabilities() Capabilities {
+	return this.caps
+}
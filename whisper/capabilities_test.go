@@ -0,0 +1,63 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCapabilitiesFor(t *testing.T) {
+	cases := []struct {
+		ver         WinVersion
+		supported   bool
+		multiThread bool
+	}{
+		// The old check let 0.9 and 1.0 to 1.8 through, and refused 2.x
+		{WinVersion{0, 9, 0, 0}, false, false},
+		{WinVersion{1, 0, 0, 0}, false, false},
+		{WinVersion{1, 8, 0, 0}, false, false},
+		{WinVersion{1, 8, 9, 9}, false, false},
+		{WinVersion{1, 9, 0, 0}, true, false},
+		{WinVersion{1, 9, 5, 0}, true, false},
+		{WinVersion{1, 10, 0, 0}, true, true},
+		{WinVersion{1, 12, 0, 0}, true, true},
+		{WinVersion{2, 0, 0, 0}, true, true},
+	}
+
+	for _, c := range cases {
+		caps, ok := CapabilitiesFor(c.ver)
+		if ok != c.supported {
+			t.Errorf("%v supported: %v, expected %v", c.ver, ok, c.supported)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if caps.MultiThread != c.multiThread || caps.CloneableModels != c.multiThread {
+			t.Errorf("%v: %+v", c.ver, caps)
+		}
+		if !caps.Capture || caps.ParamsLayout != 1 {
+			t.Errorf("%v: %+v", c.ver, caps)
+		}
+	}
+
+	if caps, ok := CapabilitiesFor(MinimumVersion); !ok || caps.MultiThread {
+		t.Errorf("MinimumVersion %v: %+v, %v", MinimumVersion, caps, ok)
+	}
+}
+
+func TestFeatureError(t *testing.T) {
+	var err error = &FeatureError{Op: "Clone", Feature: "cloneable models", Version: WinVersion{1, 9, 0, 0}}
+	if !errors.Is(err, ErrNotSupportedByDLL) {
+		t.Fatal("FeatureError doesn't match ErrNotSupportedByDLL")
+	}
+	if !errors.Is(fmt.Errorf("loading: %w", err), ErrNotSupportedByDLL) {
+		t.Fatal("a wrapped FeatureError doesn't match ErrNotSupportedByDLL")
+	}
+	if errors.Is(errors.New("other"), ErrNotSupportedByDLL) {
+		t.Fatal("another error matches ErrNotSupportedByDLL")
+	}
+	if msg := err.Error(); msg != "Clone: cloneable models is not supported by whisper.dll version 1.9.0.0" {
+		t.Fatalf("message %q", msg)
+	}
+}
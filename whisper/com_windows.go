@@ -0,0 +1,80 @@
+package whisper
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// goComObject is the native memory of a COM object implemented in Go.
+// The vtable callbacks receive a pointer to it, and find the Go state of the object through handle.
+// It lives in LocalAlloc memory because native code keeps the pointer for as long as it holds a reference.
+type goComObject struct {
+	lpVtbl unsafe.Pointer
+	refs   int32
+	handle uintptr
+}
+
+// finalizer is implemented by Go state which needs to know when the last reference is released
+type finalizer interface {
+	final()
+}
+
+const lmemZeroInit = 0x40
+
+// {00000000-0000-0000-C000-000000000046}
+var iidIUnknown = windows.GUID{Data1: 0, Data2: 0, Data3: 0, Data4: [8]byte{0xC0, 0, 0, 0, 0, 0, 0, 0x46}}
+
+// newGoComObject creates an object with one reference
+func newGoComObject(vtbl unsafe.Pointer, state any) (*goComObject, error) {
+	addr, err := windows.LocalAlloc(lmemZeroInit, uint32(unsafe.Sizeof(goComObject{})))
+	if err != nil {
+		return nil, err
+	}
+
+	this := (*goComObject)(syscallPointer(addr))
+	this.lpVtbl = vtbl
+	this.refs = 1
+	this.handle = handles.add(state)
+	return this, nil
+}
+
+func (this *goComObject) state() any {
+	return handles.get(this.handle)
+}
+
+func (this *goComObject) addRef() uintptr {
+	return uintptr(atomic.AddInt32(&this.refs, 1))
+}
+
+func (this *goComObject) release() uintptr {
+	refs := atomic.AddInt32(&this.refs, -1)
+	if refs != 0 {
+		return uintptr(refs)
+	}
+
+	state := this.state()
+	handles.remove(this.handle)
+	windows.LocalFree(windows.Handle(uintptr(unsafe.Pointer(this))))
+
+	if f, ok := state.(finalizer); ok {
+		f.final()
+	}
+	return 0
+}
+
+// queryInterface implements QueryInterface for objects which only expose IUnknown and their own IID
+func (this *goComObject) queryInterface(riid *windows.GUID, ppv *uintptr, iid *windows.GUID) uintptr {
+	if ppv == nil {
+		return uintptr(windows.E_POINTER)
+	}
+	if riid == nil || (*riid != iidIUnknown && (iid == nil || *riid != *iid)) {
+		*ppv = 0
+		return uintptr(windows.E_NOINTERFACE)
+	}
+
+	this.addRef()
+	*ppv = uintptr(unsafe.Pointer(this))
+	return uintptr(windows.S_OK)
+}
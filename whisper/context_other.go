@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+// eNotImpl is the HRESULT of the methods which return one, E_NOTIMPL
+const eNotImpl = 0x80004001
+
+func (context *IContext) TimingsPrint() error {
+	return errUnsupported("TimingsPrint")
+}
+
+func (context *IContext) RunFullWith(params *FullParams, buffer *iAudioBuffer, options RunOptions) error {
+	return errUnsupported("RunFull")
+}
+
+func (context *IContext) RunStreamedWith(params *FullParams, reader *iAudioReader, options RunOptions) error {
+	return errUnsupported("RunStreamed")
+}
+
+func (this *IContext) AddRef() int32 {
+	return 0
+}
+
+func (this *IContext) Release() int32 {
+	return 0
+}
+
+func (context *IContext) FullDefaultParams(strategy eSamplingStrategy) (*FullParams, error) {
+	return nil, errUnsupported("FullDefaultParams")
+}
+
+func (context *IContext) GetModel() (*_IModel, error) {
+	return nil, errUnsupported("GetModel")
+}
+
+func (context *IContext) GetResults(flags eResultFlags, pp **ITranscribeResult) uintptr {
+	return eNotImpl
+}
+
+func (context *IContext) DetectSpeaker(time *sTimeInterval, result *eSpeakerChannel) uintptr {
+	return eNotImpl
+}
+
+func (context *IContext) Transcript(flags eResultFlags) (*Transcript, error) {
+	return nil, errUnsupported("Transcript")
+}
@@ -0,0 +1,259 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func (context *IContext) TimingsPrint() error {
+
+	//  TimingsPrint();
+	ret, _, _ := syscall.SyscallN(
+		context.lpVtbl.TimingsPrint,
+		uintptr(unsafe.Pointer(context)),
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("RunFull failed: %s\n", syscall.Errno(ret).Error())
+		return errors.New(syscall.Errno(ret).Error())
+	}
+
+	return nil
+}
+
+// RunFullWith is RunFull calling the Go callbacks of the options
+func (context *IContext) RunFullWith(params *FullParams, buffer *iAudioBuffer, options RunOptions) error {
+
+	var total time.Duration
+	if options.Progress != nil {
+		total = params.Duration()
+		if total <= 0 {
+			if samples, err := buffer.CountSamples(); err == nil {
+				total = time.Duration(samples)*time.Second/SampleRate - params.Offset()
+			}
+		}
+	}
+	_, stop := startRun(params, options, params.Offset(), total, nil)
+
+	//  runFull( const sFullParams& params, const iAudioBuffer* buffer );
+	ret, _, _ := syscall.SyscallN(
+		context.lpVtbl.RunFull,
+		uintptr(unsafe.Pointer(context)),
+
+		uintptr(unsafe.Pointer(params.cStruct)),
+		uintptr(unsafe.Pointer(buffer)),
+	)
+
+	// The finalizer of the params frees the prompt tokens the native code was reading
+	runtime.KeepAlive(params)
+
+	if windows.Handle(ret) != windows.S_OK {
+		stop(syscall.Errno(ret))
+		fmt.Printf("RunFull failed: %s\n", syscall.Errno(ret).Error())
+		return errors.New(syscall.Errno(ret).Error())
+	}
+
+	stop(nil)
+	return nil
+}
+
+// RunStreamedWith is RunStreamed calling the Go callbacks of the options
+func (context *IContext) RunStreamedWith(params *FullParams, reader *iAudioReader, options RunOptions) error {
+
+	var total time.Duration
+	var position func() time.Duration
+	if options.Progress != nil {
+		total = params.Duration()
+		if total <= 0 {
+			if ticks, err := reader.GetDuration(); err == nil && ticks > 0 {
+				total = time.Duration(ticks)*100 - params.Offset()
+			}
+		}
+		position = goAudioReaderPosition(reader)
+	}
+	cb, stop := startRun(params, options, params.Offset(), total, position)
+
+	//   runStreamed( const sFullParams& params, const sProgressSink& progress, const iAudioReader* reader );
+	ret, _, _ := syscall.SyscallN(
+		context.lpVtbl.RunStreamed,
+		uintptr(unsafe.Pointer(context)),
+		uintptr(unsafe.Pointer(params.cStruct)),
+		uintptr(unsafe.Pointer(&cb)),
+		uintptr(unsafe.Pointer(reader)),
+	)
+	runtime.KeepAlive(params)
+
+	if windows.Handle(ret) != windows.S_OK {
+		stop(syscall.Errno(ret))
+		fmt.Printf("RunStreamed failed: %s\n", syscall.Errno(ret).Error())
+		return errors.New(syscall.Errno(ret).Error())
+	}
+
+	stop(nil)
+	return nil
+}
+
+func (this *IContext) AddRef() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.AddRef,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+func (this *IContext) Release() int32 {
+	ret, _, _ := syscall.Syscall(
+		this.lpVtbl.Release,
+		1,
+		uintptr(unsafe.Pointer(this)),
+		0,
+		0)
+	return int32(ret)
+}
+
+/*
+https://github.com/Const-me/Whisper/blob/f6f743c7b3570b85ccf47f74b84e06a73667ef3e/Whisper/Whisper/ContextImpl.misc.cpp
+
+Returns E_POINTER if null pointer provided in params
+Initialises params to all 0
+sets values in struct, does not malloc
+*/
+func (context *IContext) FullDefaultParams(strategy eSamplingStrategy) (*FullParams, error) {
+
+	/*
+		ERR : unreadable Only part of a ReadProcessMemory or WriteProcessMemory request was completed
+		 * not related to stratergy ... tested 0, 1 and 2 ... 2 produced E_INVALIDARG as expected
+		 * not a nil ptr to params ... nil poitner produced E_POINTER as expected
+		 * params seems to return 0x4000
+		 * !!!!!  FullParams is not a com interface !!!
+		 *   so no lpVtbl *FullParamsVtbl , no queryinterface, addref etc
+	*/
+
+	params := _newFullParams_cStruct()
+	//params := &[160]byte{}
+
+	ret, _, _ := syscall.SyscallN(
+		context.lpVtbl.FullDefaultParams,
+		uintptr(unsafe.Pointer(context)),
+		uintptr(strategy),
+		uintptr(unsafe.Pointer(params)),
+	)
+
+	// nil ptr should be 0x80004003L
+	// unsafe.Pointer(0xc00011dc28)
+	// unsafe.Pointer(0x4000)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("FullDefaultParams failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+
+	}
+
+	if params == nil {
+		return nil, errors.New("FullDefaultParams did not return params")
+	}
+	ParamObj := NewFullParams(params)
+	ParamObj.context = context
+
+	if ParamObj.TestDefaultsOK() {
+		return ParamObj, nil
+	}
+
+	return nil, nil
+}
+
+func (context *IContext) GetModel() (*_IModel, error) {
+
+	var modelptr *_IModel
+
+	// getModel( iModel** pp );
+	ret, _, _ := syscall.SyscallN(
+		context.lpVtbl.GetModel,
+		uintptr(unsafe.Pointer(context)),
+		uintptr(unsafe.Pointer(&modelptr)),
+	)
+
+	if windows.Handle(ret) != windows.S_OK {
+		fmt.Printf("FullDefaultParams failed: %s\n", syscall.Errno(ret).Error())
+		return nil, syscall.Errno(ret)
+	}
+
+	if modelptr == nil {
+		return nil, errors.New("loadModel did not return a Model")
+	}
+
+	if modelptr.lpVtbl == nil {
+		return nil, errors.New("loadModel method table is nil")
+	}
+
+	return modelptr, nil
+}
+
+func (context *IContext) GetResults(flags eResultFlags, pp **ITranscribeResult) uintptr {
+	ret, _, _ := syscall.Syscall(
+		context.lpVtbl.GetResults,
+		3,
+		uintptr(unsafe.Pointer(context)),
+		uintptr(flags),
+		uintptr(unsafe.Pointer(pp)),
+	)
+	return ret
+}
+
+// ************************************************************************************************************************************************
+// Not really implemented / tested
+// ************************************************************************************************************************************************
+
+func (context *IContext) DetectSpeaker(time *sTimeInterval, result *eSpeakerChannel) uintptr {
+	ret, _, _ := syscall.Syscall(
+		context.lpVtbl.DetectSpeaker,
+		3,
+		uintptr(unsafe.Pointer(context)),
+		uintptr(unsafe.Pointer(time)),
+		uintptr(unsafe.Pointer(result)),
+	)
+	return ret
+}
+
+// Transcript copies the current results of the context into Go memory, see NewTranscript
+func (context *IContext) Transcript(flags eResultFlags) (*Transcript, error) {
+	var result *ITranscribeResult
+
+	ret := context.GetResults(flags, &result)
+	if windows.Handle(ret) != windows.S_OK {
+		return nil, syscall.Errno(ret)
+	}
+
+	if result == nil {
+		return nil, errors.New("getResults did not return a result")
+	}
+	defer result.Release()
+
+	return NewTranscript(result)
+}
+
+// lastSegments copies the last count segments of the results, e.g. the new ones in a new segment callback,
+// without copying the earlier ones
+func (context *IContext) lastSegments(flags eResultFlags, count int) ([]Segment, error) {
+	var result *ITranscribeResult
+
+	ret := context.GetResults(flags, &result)
+	if windows.Handle(ret) != windows.S_OK {
+		return nil, syscall.Errno(ret)
+	}
+
+	if result == nil {
+		return nil, errors.New("getResults did not return a result")
+	}
+	defer result.Release()
+
+	return lastSegments(result, count)
+}
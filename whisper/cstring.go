@@ -0,0 +1,33 @@
+package whisper
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Strings of the native API, converted without cgo or x/sys/windows so they work on every platform
+
+// goString copies a null-terminated C string
+func goString(p *byte) string {
+	if p == nil {
+		return ""
+	}
+
+	n := 0
+	for *(*byte)(unsafe.Add(unsafe.Pointer(p), n)) != 0 {
+		n++
+	}
+	return string(unsafe.Slice(p, n))
+}
+
+// utf16PtrFromString converts the string to a null-terminated wchar_t string
+func utf16PtrFromString(s string) (*uint16, error) {
+	if strings.IndexByte(s, 0) >= 0 {
+		return nil, errors.New("the string contains a null character")
+	}
+
+	wide := utf16.Encode([]rune(s + "\x00"))
+	return &wide[0], nil
+}
@@ -0,0 +1,141 @@
+package whisper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DLLEnvVar is the environment variable New reads the path of whisper.dll from
+const DLLEnvVar = "WHISPER_DLL"
+
+// The exports New resolves when loading whisper.dll, a DLL missing any of them is refused
+var requiredExports = []string{
+	"setupLogger",
+	"loadModel",
+	"initMediaFoundation",
+}
+
+// Option configures New
+type Option func(*libOptions)
+
+type libOptions struct {
+	dllPath string
+}
+
+// WithDLLPath loads whisper.dll from path, New fails rather than looking anywhere else when it doesn't exist
+func WithDLLPath(path string) Option {
+	return func(options *libOptions) {
+		options.dllPath = path
+	}
+}
+
+// DLLSearch is where New looks for whisper.dll, empty fields are skipped
+type DLLSearch struct {
+	Path    string // Path of the DLL, from WithDLLPath
+	Env     string // Path of the DLL, from the WHISPER_DLL environment variable
+	ExeDir  string // Directory of the executable
+	WorkDir string // Working directory
+}
+
+// Candidates returns the paths to try in order: the option alone when set, otherwise the environment variable,
+// then whisper.dll in the directory of the executable and in the working directory
+func (this DLLSearch) Candidates() []string {
+	var candidates []string
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		path = filepath.Clean(path)
+		for _, existing := range candidates {
+			if strings.EqualFold(existing, path) {
+				return
+			}
+		}
+		candidates = append(candidates, path)
+	}
+
+	if this.Path != "" {
+		add(this.Path)
+		return candidates
+	}
+
+	add(this.Env)
+	if this.ExeDir != "" {
+		add(filepath.Join(this.ExeDir, DLLName))
+	}
+	if this.WorkDir != "" {
+		add(filepath.Join(this.WorkDir, DLLName))
+	}
+	return candidates
+}
+
+// Resolve returns the first candidate for which exists is true.
+// A Path which doesn't exist is an error, the other places are not searched then.
+func (this DLLSearch) Resolve(exists func(path string) bool) (string, error) {
+	candidates := this.Candidates()
+	if this.Path != "" && !exists(candidates[0]) {
+		return "", &DLLNotFoundError{Tried: candidates, Explicit: true}
+	}
+
+	for _, path := range candidates {
+		if exists(path) {
+			return path, nil
+		}
+	}
+	return "", &DLLNotFoundError{Tried: candidates}
+}
+
+// dllSearchFor fills the search from the options and the environment of the process
+func dllSearchFor(opts []Option) DLLSearch {
+	options := libOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	search := DLLSearch{
+		Path: options.dllPath,
+		Env:  os.Getenv(DLLEnvVar),
+	}
+	if exe, err := os.Executable(); err == nil {
+		search.ExeDir = filepath.Dir(exe)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		search.WorkDir = wd
+	}
+	return search
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// DLLNotFoundError is returned by New when whisper.dll is in none of the searched places
+type DLLNotFoundError struct {
+	Tried []string
+	// The path was given with WithDLLPath
+	Explicit bool
+}
+
+func (this *DLLNotFoundError) Error() string {
+	if this.Explicit {
+		return fmt.Sprintf("%s not found at %s, the path given with WithDLLPath", DLLName, this.Tried[0])
+	}
+	if len(this.Tried) == 0 {
+		return DLLName + " not found, nowhere to look for it"
+	}
+	return DLLName + " not found, tried: " + strings.Join(this.Tried, ", ")
+}
+
+// MissingExportsError is returned by New when whisper.dll lacks functions the bindings call,
+// usually because it is a different version
+type MissingExportsError struct {
+	Path    string
+	Missing []string
+}
+
+func (this *MissingExportsError) Error() string {
+	return fmt.Sprintf("%s does not export %s", this.Path, strings.Join(this.Missing, ", "))
+}
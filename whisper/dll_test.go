@@ -0,0 +1,56 @@
+package whisper
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDLLCandidates(t *testing.T) {
+	search := DLLSearch{
+		Env:     filepath.Join("env", DLLName),
+		ExeDir:  "exe",
+		WorkDir: "exe",
+	}
+
+	// The working directory is the directory of the executable, it is only tried once
+	want := []string{filepath.Join("env", DLLName), filepath.Join("exe", DLLName)}
+	if got := search.Candidates(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("candidates %q, expected %q", got, want)
+	}
+
+	exists := func(path string) bool { return path == filepath.Join("exe", DLLName) }
+	if got, err := search.Resolve(exists); err != nil || got != filepath.Join("exe", DLLName) {
+		t.Fatalf("Resolve returned %q, %v", got, err)
+	}
+
+	var notFound *DLLNotFoundError
+	_, err := search.Resolve(func(string) bool { return false })
+	if !errors.As(err, &notFound) || len(notFound.Tried) != 2 {
+		t.Fatalf("Resolve returned %v, expected every candidate tried", err)
+	}
+}
+
+func TestDLLPathIsAuthoritative(t *testing.T) {
+	search := DLLSearch{
+		Path:    filepath.Join("missing", DLLName),
+		ExeDir:  "exe",
+		WorkDir: "work",
+	}
+
+	// Another whisper.dll exists, but the explicit path doesn't
+	_, err := search.Resolve(func(path string) bool { return path != search.Path })
+
+	var notFound *DLLNotFoundError
+	if !errors.As(err, &notFound) || !notFound.Explicit {
+		t.Fatalf("Resolve returned %v, expected the explicit path to be missing", err)
+	}
+	if !strings.Contains(err.Error(), search.Path) {
+		t.Fatalf("the error does not name the path: %s", err)
+	}
+
+	if got, err := search.Resolve(func(string) bool { return true }); err != nil || got != search.Path {
+		t.Fatalf("Resolve returned %q, %v", got, err)
+	}
+}
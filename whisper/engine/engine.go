@@ -0,0 +1,80 @@
+/*
+Package engine describes the whisper bindings as interfaces, so code written against them runs unchanged
+with whisper.dll, see Native, and with the in-memory backend of the fake package.
+*/
+package engine
+
+import (
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+)
+
+// Engine loads models and audio, whisper.Libwhisper through Native
+type Engine interface {
+	Version() string
+
+	LoadModel(path string) (Model, error)
+
+	// LoadAudio decodes an audio file to 16 kHz PCM
+	LoadAudio(path string, stereo bool) (AudioBuffer, error)
+	// NewAudioBuffer copies 16 kHz PCM into a buffer
+	NewAudioBuffer(pcm *whisper.PcmBuffer) (AudioBuffer, error)
+
+	// Close releases what the engine created besides models and buffers, e.g. Media Foundation
+	Close() error
+}
+
+// Model creates contexts, whisper.Model through Native
+type Model interface {
+	IsMultilingual() bool
+	Tokenize(text string) ([]int32, error)
+
+	NewContext() (Context, error)
+	Release() int32
+}
+
+// Context transcribes audio, whisper.IContext through Native.
+// A context runs one transcription at a time.
+type Context interface {
+	// Run transcribes the buffer, the results replace the ones of the previous run.
+	// params may be nil for the defaults of the greedy strategy.
+	Run(params *whisper.Params, buffer AudioBuffer, options whisper.RunOptions) error
+
+	// Results returns the results of the last run, which stay valid after the next one.
+	// The segments and tokens always have their times, the tokens are only returned when requested.
+	Results(tokens bool) (Result, error)
+
+	Release() int32
+}
+
+// AudioBuffer is 16 kHz PCM, implemented by the iAudioBuffer of whisper.IMediaFoundation.LoadAudioFile
+// and whisper.NewAudioBuffer
+type AudioBuffer interface {
+	CountSamples() (uint32, error)
+	Pcm() (*whisper.PcmBuffer, error)
+	// GetTime is the start time of the buffer
+	GetTime() (time.Duration, error)
+	Release() int32
+}
+
+// Result is the output of a run, implemented by whisper.ITranscribeResult
+type Result interface {
+	Transcript() (*whisper.Transcript, error)
+	Release() int32
+}
+
+// Transcribe runs the context over the buffer, and copies the results
+func Transcribe(context Context, params *whisper.Params, buffer AudioBuffer, options whisper.RunOptions) (*whisper.Transcript, error) {
+	if err := context.Run(params, buffer, options); err != nil {
+		return nil, err
+	}
+
+	result, err := context.Results(options.Flags&whisper.RfTokens != 0)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Release()
+
+	return result.Transcript()
+}
@@ -0,0 +1,202 @@
+package engine_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+	"github.com/jaybinks/goConstmeWhisper/whisper/engine"
+	"github.com/jaybinks/goConstmeWhisper/whisper/engine/fake"
+)
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// timeline returns PCM whose samples are their own index, so a script can tell which part of the audio it got
+func timeline(d time.Duration) *whisper.PcmBuffer {
+	pcm := &whisper.PcmBuffer{Mono: make([]float32, int(d*whisper.SampleRate/time.Second))}
+	for i := range pcm.Mono {
+		pcm.Mono[i] = float32(i)
+	}
+	return pcm
+}
+
+// progressLog collects every update of a reporter
+type progressLog struct {
+	fractions []float64
+}
+
+func (this *progressLog) reporter() *whisper.ProgressReporter {
+	return whisper.NewProgressReporter(func(p whisper.Progress) {
+		this.fractions = append(this.fractions, p.Fraction)
+	}, time.Nanosecond)
+}
+
+func (this *progressLog) check(t *testing.T, done bool) {
+	t.Helper()
+	for i := 1; i < len(this.fractions); i++ {
+		if this.fractions[i] < this.fractions[i-1] {
+			t.Fatalf("progress went backwards: %v", this.fractions)
+		}
+	}
+	finished := len(this.fractions) > 0 && this.fractions[len(this.fractions)-1] == 1
+	if finished != done {
+		t.Fatalf("progress %v, expected done = %v", this.fractions, done)
+	}
+}
+
+func newContext(t *testing.T, eng engine.Engine) engine.Context {
+	model, err := eng.LoadModel("model.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { model.Release() })
+
+	context, err := model.NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { context.Release() })
+	return context
+}
+
+func TestTranscribe(t *testing.T) {
+	eng := fake.New(fake.Script{Segments: []whisper.Segment{
+		{Text: " one", Begin: 0, End: seconds(1)},
+		{Text: " two", Begin: seconds(1), End: seconds(2)},
+		{Text: " three", Begin: seconds(2), End: seconds(3)},
+	}})
+	context := newContext(t, eng)
+
+	buffer, err := eng.NewAudioBuffer(timeline(3 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := whisper.NewParams(whisper.SsGreedy, whisper.WithWindow(seconds(1), seconds(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var delivered []string
+	var progress progressLog
+	transcript, err := engine.Transcribe(context, params, buffer, whisper.RunOptions{
+		Progress:   progress.reporter(),
+		OnSegments: func(segments []whisper.Segment) { delivered = append(delivered, segments[0].Text) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the segment within the window of the params
+	if transcript.Text() != "two" || fmt.Sprint(delivered) != "[ two]" {
+		t.Fatalf("transcript %q, delivered %q", transcript.Text(), delivered)
+	}
+	progress.check(t, true)
+
+	runs := eng.Runs()
+	if len(runs) != 1 || runs[0].Params != params || runs[0].Model != "model.bin" {
+		t.Fatalf("runs %+v", runs)
+	}
+}
+
+func TestTranscribeErrors(t *testing.T) {
+	failure := errors.New("device lost")
+	eng := fake.New(fake.Script{
+		Segments: []whisper.Segment{{Text: " one", End: seconds(1)}},
+		RunErr:   failure,
+	})
+	context := newContext(t, eng)
+
+	buffer, _ := eng.NewAudioBuffer(timeline(2 * time.Second))
+	var progress progressLog
+	if _, err := engine.Transcribe(context, nil, buffer, whisper.RunOptions{Progress: progress.reporter()}); err != failure {
+		t.Fatalf("Transcribe returned %v, expected the run error", err)
+	}
+	progress.check(t, false)
+
+	if _, err := fake.New(fake.Script{LoadModelErr: failure}).LoadModel("model.bin"); err != failure {
+		t.Fatalf("LoadModel returned %v, expected the script error", err)
+	}
+}
+
+func TestModelsReleased(t *testing.T) {
+	eng := fake.New(fake.Script{})
+	model, err := eng.LoadModel("model.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eng.OpenModels() != 1 {
+		t.Fatalf("%d open models after LoadModel", eng.OpenModels())
+	}
+	model.Release()
+	if eng.OpenModels() != 0 {
+		t.Fatalf("%d open models after Release", eng.OpenModels())
+	}
+}
+
+// engineWindows transcribes every window of the PCM with a run of the engine
+type engineWindows struct {
+	engine  engine.Engine
+	context engine.Context
+	pcm     *whisper.PcmBuffer
+}
+
+func (this *engineWindows) TranscribeWindow(window whisper.Window) (*whisper.Transcript, error) {
+	begin := int(window.Begin * whisper.SampleRate / time.Second)
+	end := int(window.End * whisper.SampleRate / time.Second)
+	if end > len(this.pcm.Mono) {
+		end = len(this.pcm.Mono)
+	}
+
+	buffer, err := this.engine.NewAudioBuffer(&whisper.PcmBuffer{Mono: this.pcm.Mono[begin:end]})
+	if err != nil {
+		return nil, err
+	}
+	defer buffer.Release()
+
+	return engine.Transcribe(this.context, nil, buffer, whisper.RunOptions{})
+}
+
+func TestChunked(t *testing.T) {
+	total := 50 * time.Second
+	var truth []whisper.Segment
+	for begin := time.Duration(0); begin+seconds(3.3) <= total; begin += seconds(3.3) {
+		truth = append(truth, whisper.Segment{Text: fmt.Sprintf(" s%d", len(truth)), Begin: begin, End: begin + seconds(3.3)})
+	}
+
+	// Every run hears the segments centered within its audio, with times relative to the audio
+	eng := fake.New(fake.Script{Transcribe: func(run fake.Run) ([]whisper.Segment, error) {
+		start := time.Duration(run.Pcm.Mono[0]) * time.Second / whisper.SampleRate
+		length := run.Pcm.Duration()
+
+		var segments []whisper.Segment
+		for _, seg := range truth {
+			if center := seg.Begin + (seg.End-seg.Begin)/2; center >= start && center < start+length {
+				seg.Begin -= start
+				seg.End -= start
+				segments = append(segments, seg)
+			}
+		}
+		return segments, nil
+	}})
+
+	windows := &engineWindows{engine: eng, context: newContext(t, eng), pcm: timeline(total)}
+	var progress progressLog
+	options := whisper.ChunkOptions{Window: 20 * time.Second, Overlap: 4 * time.Second, Progress: progress.reporter()}
+
+	transcript, err := whisper.TranscribeChunked(windows, total, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := transcript.Text(), (&whisper.Transcript{Segments: truth}).Text(); got != want {
+		t.Fatalf("stitched %q\nexpected %q", got, want)
+	}
+	if planned, _ := whisper.PlanWindows(total, options); len(eng.Runs()) != len(planned) {
+		t.Fatalf("%d runs for %d windows", len(eng.Runs()), len(planned))
+	}
+	progress.check(t, true)
+}
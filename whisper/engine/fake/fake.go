@@ -0,0 +1,411 @@
+/*
+Package fake is an in-memory engine.Engine for tests, which never loads whisper.dll.
+Every run returns the segments of a Script, reports progress and calls the callbacks of the run options
+as the native engine does, and records the call so tests can check what was transcribed.
+*/
+package fake
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+	"github.com/jaybinks/goConstmeWhisper/whisper/engine"
+)
+
+// Script configures what the fake engine returns. Zero values are valid: no segments and no errors.
+type Script struct {
+	Version      string
+	Multilingual bool
+
+	// Segments of every run, with their tokens. Times are from the start of the buffer:
+	// a run only returns the segments within the buffer, and the window of its params.
+	Segments []whisper.Segment
+
+	// Replaces Segments when set, e.g. to return different segments for every run
+	Transcribe func(run Run) ([]whisper.Segment, error)
+
+	// Tokenize returns one token per word when nil
+	Tokenize func(text string) ([]int32, error)
+
+	// How long every run takes, the progress is reported over that time.
+	// The segments are delivered to RunOptions.OnSegments as the simulated time passes their end.
+	RunTime time.Duration
+
+	// Errors returned by the engine, by the model and by the context
+	LoadModelErr  error
+	LoadAudioErr  error
+	NewContextErr error
+	RunErr        error
+	ResultsErr    error
+}
+
+// Run records one call to Context.Run
+type Run struct {
+	// Path of the model of the context
+	Model  string
+	Params *whisper.Params
+	Pcm    *whisper.PcmBuffer
+	Start  time.Duration
+}
+
+// Engine is the fake engine.Engine
+type Engine struct {
+	script Script
+
+	mutex  sync.Mutex
+	runs   []Run
+	models int
+	closed bool
+}
+
+// New creates a fake engine running the script
+func New(script Script) *Engine {
+	return &Engine{script: script}
+}
+
+// Runs returns the runs of every context of the engine, in order
+func (this *Engine) Runs() []Run {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return append([]Run(nil), this.runs...)
+}
+
+// OpenModels is the number of models which were not released
+func (this *Engine) OpenModels() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.models
+}
+
+func (this *Engine) Version() string {
+	if this.script.Version == "" {
+		return "fake"
+	}
+	return this.script.Version
+}
+
+func (this *Engine) LoadModel(path string) (engine.Model, error) {
+	if this.script.LoadModelErr != nil {
+		return nil, this.script.LoadModelErr
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.closed {
+		return nil, errors.New("fake: the engine is closed")
+	}
+	this.models++
+	return &model{engine: this, path: path, refs: 1}, nil
+}
+
+// LoadAudio decodes a WAV file, the only format the fake engine understands
+func (this *Engine) LoadAudio(path string, stereo bool) (engine.AudioBuffer, error) {
+	if this.script.LoadAudioErr != nil {
+		return nil, this.script.LoadAudioErr
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pcm, err := whisper.DecodeWav(file, stereo)
+	if err != nil {
+		return nil, err
+	}
+	return NewAudioBuffer(pcm, 0), nil
+}
+
+func (this *Engine) NewAudioBuffer(pcm *whisper.PcmBuffer) (engine.AudioBuffer, error) {
+	if pcm == nil {
+		return nil, errors.New("fake: pcm is nil")
+	}
+	return NewAudioBuffer(pcm, 0), nil
+}
+
+func (this *Engine) Close() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.closed = true
+	return nil
+}
+
+func (this *Engine) record(run Run) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.runs = append(this.runs, run)
+}
+
+// ************************************************************
+
+type model struct {
+	engine *Engine
+	path   string
+
+	mutex sync.Mutex
+	refs  int32
+}
+
+func (this *model) IsMultilingual() bool {
+	return this.engine.script.Multilingual
+}
+
+func (this *model) Tokenize(text string) ([]int32, error) {
+	if this.engine.script.Tokenize != nil {
+		return this.engine.script.Tokenize(text)
+	}
+
+	words := strings.Fields(text)
+	tokens := make([]int32, len(words))
+	for i := range words {
+		tokens[i] = int32(i)
+	}
+	return tokens, nil
+}
+
+func (this *model) NewContext() (engine.Context, error) {
+	if this.engine.script.NewContextErr != nil {
+		return nil, this.engine.script.NewContextErr
+	}
+	return &context{model: this, refs: 1}, nil
+}
+
+func (this *model) Release() int32 {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.refs == 0 {
+		return 0
+	}
+	this.refs--
+	if this.refs == 0 {
+		this.engine.mutex.Lock()
+		this.engine.models--
+		this.engine.mutex.Unlock()
+	}
+	return this.refs
+}
+
+// ************************************************************
+
+type context struct {
+	model *model
+	refs  int32
+
+	mutex    sync.Mutex
+	running  bool
+	segments []whisper.Segment
+}
+
+func (this *context) Run(params *whisper.Params, buffer engine.AudioBuffer, options whisper.RunOptions) error {
+	if buffer == nil {
+		return errors.New("Run: buffer is nil")
+	}
+
+	this.mutex.Lock()
+	if this.running {
+		this.mutex.Unlock()
+		return errors.New("fake: the context is already running")
+	}
+	this.running = true
+	this.mutex.Unlock()
+
+	defer func() {
+		this.mutex.Lock()
+		this.running = false
+		this.mutex.Unlock()
+	}()
+
+	pcm, err := buffer.Pcm()
+	if err != nil {
+		return err
+	}
+	start, err := buffer.GetTime()
+	if err != nil {
+		return err
+	}
+
+	run := Run{Model: this.model.path, Params: params, Pcm: pcm, Start: start}
+	script := &this.model.engine.script
+	this.model.engine.record(run)
+
+	var segments []whisper.Segment
+	if script.Transcribe != nil {
+		if segments, err = script.Transcribe(run); err != nil {
+			return err
+		}
+	} else {
+		segments = script.Segments
+	}
+
+	// The part of the audio the run transcribes, segment times are from the start of the buffer
+	begin, end := time.Duration(0), pcm.Duration()
+	if params != nil {
+		offset, duration := params.Window()
+		begin = offset
+		if duration > 0 && offset+duration < end {
+			end = offset + duration
+		}
+	}
+	segments = window(segments, begin, end)
+
+	this.simulate(segments, begin, end-begin, options)
+	if script.RunErr != nil {
+		return script.RunErr
+	}
+
+	this.mutex.Lock()
+	this.segments = segments
+	this.mutex.Unlock()
+	return nil
+}
+
+// simulate delivers the segments and reports the progress over the run time
+func (this *context) simulate(segments []whisper.Segment, begin, total time.Duration, options whisper.RunOptions) {
+	options.Progress.Start()
+	options.Progress.Report(0)
+
+	runTime := this.model.engine.script.RunTime
+	started := time.Now()
+	for i := range segments {
+		if runTime > 0 && total > 0 {
+			at := time.Duration(float64(runTime) * float64(segments[i].End-begin) / float64(total))
+			time.Sleep(time.Until(started.Add(at)))
+		}
+
+		if options.OnSegments != nil {
+			options.OnSegments([]whisper.Segment{copySegment(segments[i], options.Flags&whisper.RfTokens != 0)})
+		}
+		if total > 0 {
+			options.Progress.Report(float64(segments[i].End-begin) / float64(total))
+		}
+	}
+	if runTime > 0 {
+		time.Sleep(time.Until(started.Add(runTime)))
+	}
+
+	if this.model.engine.script.RunErr == nil {
+		options.Progress.Done()
+	}
+}
+
+func (this *context) Results(tokens bool) (engine.Result, error) {
+	if this.model.engine.script.ResultsErr != nil {
+		return nil, this.model.engine.script.ResultsErr
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	copied := make([]whisper.Segment, len(this.segments))
+	for i := range this.segments {
+		copied[i] = copySegment(this.segments[i], tokens)
+	}
+	return &result{transcript: whisper.Transcript{Segments: copied}}, nil
+}
+
+func (this *context) Release() int32 {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.refs > 0 {
+		this.refs--
+	}
+	return this.refs
+}
+
+// window returns the segments which end after begin and start before end
+func window(segments []whisper.Segment, begin, end time.Duration) []whisper.Segment {
+	var result []whisper.Segment
+	for _, seg := range segments {
+		if seg.End > begin && seg.Begin < end {
+			result = append(result, seg)
+		}
+	}
+	return result
+}
+
+// copySegment copies the segment, without its tokens unless requested
+func copySegment(seg whisper.Segment, tokens bool) whisper.Segment {
+	if tokens && len(seg.Tokens) > 0 {
+		seg.Tokens = append([]whisper.Token(nil), seg.Tokens...)
+	} else {
+		seg.Tokens = nil
+	}
+	return seg
+}
+
+// ************************************************************
+
+type result struct {
+	transcript whisper.Transcript
+}
+
+func (this *result) Transcript() (*whisper.Transcript, error) {
+	copied := whisper.Transcript{Segments: make([]whisper.Segment, len(this.transcript.Segments))}
+	for i := range this.transcript.Segments {
+		copied.Segments[i] = copySegment(this.transcript.Segments[i], true)
+	}
+	return &copied, nil
+}
+
+func (this *result) Release() int32 {
+	return 0
+}
+
+// ************************************************************
+
+// AudioBuffer is the engine.AudioBuffer of the fake engine, it holds a copy of the PCM
+type AudioBuffer struct {
+	pcm   whisper.PcmBuffer
+	start time.Duration
+}
+
+// NewAudioBuffer copies the PCM, start is returned by GetTime
+func NewAudioBuffer(pcm *whisper.PcmBuffer, start time.Duration) *AudioBuffer {
+	return &AudioBuffer{
+		pcm: whisper.PcmBuffer{
+			Mono:   append([]float32(nil), pcm.Mono...),
+			Stereo: append([]float32(nil), pcm.Stereo...),
+		},
+		start: start,
+	}
+}
+
+func (this *AudioBuffer) CountSamples() (uint32, error) {
+	return uint32(len(this.pcm.Mono)), nil
+}
+
+func (this *AudioBuffer) Pcm() (*whisper.PcmBuffer, error) {
+	return &whisper.PcmBuffer{
+		Mono:   append([]float32(nil), this.pcm.Mono...),
+		Stereo: append([]float32(nil), this.pcm.Stereo...),
+	}, nil
+}
+
+func (this *AudioBuffer) GetTime() (time.Duration, error) {
+	return this.start, nil
+}
+
+func (this *AudioBuffer) Release() int32 {
+	return 0
+}
+
+// Check the fake implements every interface
+var (
+	_ engine.Engine      = (*Engine)(nil)
+	_ engine.Model       = (*model)(nil)
+	_ engine.Context     = (*context)(nil)
+	_ engine.Result      = (*result)(nil)
+	_ engine.AudioBuffer = (*AudioBuffer)(nil)
+)
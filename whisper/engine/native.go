@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+)
+
+// The adapters of whisper.dll. Buffers and results are the native objects themselves.
+// Models and contexts can't be: Model.NewContext returns a Context and Context.Results a Result,
+// which the whisper package can't name since this package imports it. So the wrappers embed the native types,
+// which keeps every other method theirs, and only add the methods naming engine types.
+// Libwhisper is wrapped too, it holds the GPU and the Media Foundation of the engine.
+
+// The native types implement every method of the interfaces but those
+var (
+	_ interface {
+		IsMultilingual() bool
+		Tokenize(text string) ([]int32, error)
+		Release() int32
+	} = (*whisper.Model)(nil)
+	_ interface {
+		Release() int32
+	} = (*whisper.IContext)(nil)
+	_ Result = (*whisper.ITranscribeResult)(nil)
+
+	_ Engine  = (*nativeEngine)(nil)
+	_ Model   = (*nativeModel)(nil)
+	_ Context = (*nativeContext)(nil)
+)
+
+type nativeEngine struct {
+	lib *whisper.Libwhisper
+	gpu string
+
+	mutex sync.Mutex
+	mf    *whisper.IMediaFoundation
+}
+
+// Native returns the Engine of whisper.dll, loading models on the GPU named gpu, or the default one when empty
+func Native(lib *whisper.Libwhisper, gpu string) Engine {
+	return &nativeEngine{lib: lib, gpu: gpu}
+}
+
+func (this *nativeEngine) Version() string {
+	return this.lib.Version()
+}
+
+func (this *nativeEngine) LoadModel(path string) (Model, error) {
+	model, err := this.lib.LoadModel(path, this.gpu)
+	if err != nil {
+		return nil, err
+	}
+	return &nativeModel{Model: model}, nil
+}
+
+// mediaFoundation starts Media Foundation on first use
+func (this *nativeEngine) mediaFoundation() (*whisper.IMediaFoundation, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.mf == nil {
+		mf, err := this.lib.InitMediaFoundation()
+		if err != nil {
+			return nil, err
+		}
+		this.mf = mf
+	}
+	return this.mf, nil
+}
+
+func (this *nativeEngine) LoadAudio(path string, stereo bool) (AudioBuffer, error) {
+	mf, err := this.mediaFoundation()
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := mf.LoadAudioFile(path, stereo)
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+func (this *nativeEngine) NewAudioBuffer(pcm *whisper.PcmBuffer) (AudioBuffer, error) {
+	buffer, err := whisper.NewAudioBuffer(pcm, 0)
+	if err != nil {
+		return nil, err
+	}
+	return buffer, nil
+}
+
+func (this *nativeEngine) Close() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.mf != nil {
+		this.mf.Release()
+		this.mf = nil
+	}
+	return nil
+}
+
+// ************************************************************
+
+type nativeModel struct {
+	*whisper.Model
+}
+
+func (this *nativeModel) NewContext() (Context, error) {
+	context, err := this.Model.CreateContext()
+	if err != nil {
+		return nil, err
+	}
+	if context == nil {
+		return nil, errors.New("createContext did not return a context")
+	}
+	return &nativeContext{IContext: context}, nil
+}
+
+// ************************************************************
+
+type nativeContext struct {
+	*whisper.IContext
+}
+
+func (this *nativeContext) Run(params *whisper.Params, buffer AudioBuffer, options whisper.RunOptions) error {
+	if buffer == nil {
+		return errors.New("Run: buffer is nil")
+	}
+
+	if params == nil {
+		var err error
+		if params, err = whisper.NewParams(whisper.SsGreedy); err != nil {
+			return err
+		}
+	}
+	fullParams, err := this.IContext.FullParamsFrom(params)
+	if err != nil {
+		return err
+	}
+
+	if native, ok := whisper.AsAudioBuffer(buffer); ok {
+		return this.IContext.RunFullWith(fullParams, native, options)
+	}
+
+	// Other implementations are copied into a native buffer
+	pcm, err := buffer.Pcm()
+	if err != nil {
+		return err
+	}
+	start, err := buffer.GetTime()
+	if err != nil {
+		return err
+	}
+	native, err := whisper.NewAudioBuffer(pcm, start)
+	if err != nil {
+		return err
+	}
+	defer native.Release()
+
+	return this.IContext.RunFullWith(fullParams, native, options)
+}
+
+// The flags of Results. Without RfTimestamps whisper.dll leaves the times of the segments and tokens unset,
+// the fake backend always has them.
+var (
+	resultFlags       = whisper.RfNone | whisper.RfNewObject | whisper.RfTimestamps
+	resultFlagsTokens = resultFlags | whisper.RfTokens
+)
+
+func (this *nativeContext) Results(tokens bool) (Result, error) {
+	flags := resultFlags
+	if tokens {
+		flags = resultFlagsTokens
+	}
+
+	var result *whisper.ITranscribeResult
+	if ret := this.IContext.GetResults(flags, &result); ret != 0 {
+		return nil, fmt.Errorf("getResults failed: %w", syscall.Errno(ret))
+	}
+	if result == nil {
+		return nil, errors.New("getResults did not return a result")
+	}
+	return result, nil
+}
@@ -0,0 +1,24 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper"
+)
+
+func TestResultFlags(t *testing.T) {
+	for _, flags := range []uint32{uint32(resultFlags), uint32(resultFlagsTokens)} {
+		if flags&whisper.RfTimestamps == 0 {
+			t.Errorf("flags %#x lack RfTimestamps", flags)
+		}
+		if flags&whisper.RfNewObject == 0 {
+			t.Errorf("flags %#x lack RfNewObject, the result would be replaced by the next run", flags)
+		}
+	}
+	if resultFlags&whisper.RfTokens != 0 {
+		t.Error("tokens requested without asking for them")
+	}
+	if resultFlagsTokens&whisper.RfTokens == 0 {
+		t.Error("tokens not requested")
+	}
+}
@@ -1,207 +1,473 @@
 package whisper
 
+import (
+	"fmt"
+	"strings"
+)
+
 // https://github.com/Const-me/Whisper/blob/master/WhisperNet/API/eLanguage.cs
 
 type eLanguage int32
 
 const (
-	Auto eLanguage = -1 // "af"
+	Auto eLanguage = -1 // "auto"
 
-	Afrikaans = 0x6661 // "af"
+	Afrikaans eLanguage = 0x6661 // "af"
 	/// <summary>Albanian</summary>
-	Albanian = 0x7173 // "sq"
+	Albanian eLanguage = 0x7173 // "sq"
 	/// <summary>Amharic</summary>
-	Amharic = 0x6D61 // "am"
+	Amharic eLanguage = 0x6D61 // "am"
 	/// <summary>Arabic</summary>
-	Arabic = 0x7261 // "ar"
+	Arabic eLanguage = 0x7261 // "ar"
 	/// <summary>Armenian</summary>
-	Armenian = 0x7968 // "hy"
+	Armenian eLanguage = 0x7968 // "hy"
 	/// <summary>Assamese</summary>
-	Assamese = 0x7361 // "as"
+	Assamese eLanguage = 0x7361 // "as"
 	/// <summary>Azerbaijani</summary>
-	Azerbaijani = 0x7A61 // "az"
+	Azerbaijani eLanguage = 0x7A61 // "az"
 	/// <summary>Bashkir</summary>
-	Bashkir = 0x6162 // "ba"
+	Bashkir eLanguage = 0x6162 // "ba"
 	/// <summary>Basque</summary>
-	Basque = 0x7565 // "eu"
+	Basque eLanguage = 0x7565 // "eu"
 	/// <summary>Belarusian</summary>
-	Belarusian = 0x6562 // "be"
+	Belarusian eLanguage = 0x6562 // "be"
 	/// <summary>Bengali</summary>
-	Bengali = 0x6E62 // "bn"
+	Bengali eLanguage = 0x6E62 // "bn"
 	/// <summary>Bosnian</summary>
-	Bosnian = 0x7362 // "bs"
+	Bosnian eLanguage = 0x7362 // "bs"
 	/// <summary>Breton</summary>
-	Breton = 0x7262 // "br"
+	Breton eLanguage = 0x7262 // "br"
 	/// <summary>Bulgarian</summary>
-	Bulgarian = 0x6762 // "bg"
+	Bulgarian eLanguage = 0x6762 // "bg"
 	/// <summary>Catalan</summary>
-	Catalan = 0x6163 // "ca"
+	Catalan eLanguage = 0x6163 // "ca"
 	/// <summary>Chinese</summary>
-	Chinese = 0x687A // "zh"
+	Chinese eLanguage = 0x687A // "zh"
 	/// <summary>Croatian</summary>
-	Croatian = 0x7268 // "hr"
+	Croatian eLanguage = 0x7268 // "hr"
 	/// <summary>Czech</summary>
-	Czech = 0x7363 // "cs"
+	Czech eLanguage = 0x7363 // "cs"
 	/// <summary>Danish</summary>
-	Danish = 0x6164 // "da"
+	Danish eLanguage = 0x6164 // "da"
 	/// <summary>Dutch</summary>
-	Dutch = 0x6C6E // "nl"
+	Dutch eLanguage = 0x6C6E // "nl"
 	/// <summary>English</summary>
-	English = 0x6E65 // "en"
+	English eLanguage = 0x6E65 // "en"
 	/// <summary>Estonian</summary>
-	Estonian = 0x7465 // "et"
+	Estonian eLanguage = 0x7465 // "et"
 	/// <summary>Faroese</summary>
-	Faroese = 0x6F66 // "fo"
+	Faroese eLanguage = 0x6F66 // "fo"
 	/// <summary>Finnish</summary>
-	Finnish = 0x6966 // "fi"
+	Finnish eLanguage = 0x6966 // "fi"
 	/// <summary>French</summary>
-	French = 0x7266 // "fr"
+	French eLanguage = 0x7266 // "fr"
 	/// <summary>Galician</summary>
-	Galician = 0x6C67 // "gl"
+	Galician eLanguage = 0x6C67 // "gl"
 	/// <summary>Georgian</summary>
-	Georgian = 0x616B // "ka"
+	Georgian eLanguage = 0x616B // "ka"
 	/// <summary>German</summary>
-	German = 0x6564 // "de"
+	German eLanguage = 0x6564 // "de"
 	/// <summary>Greek</summary>
-	Greek = 0x6C65 // "el"
+	Greek eLanguage = 0x6C65 // "el"
 	/// <summary>Gujarati</summary>
-	Gujarati = 0x7567 // "gu"
+	Gujarati eLanguage = 0x7567 // "gu"
 	/// <summary>Haitian Creole</summary>
-	HaitianCreole = 0x7468 // "ht"
+	HaitianCreole eLanguage = 0x7468 // "ht"
 	/// <summary>Hausa</summary>
-	Hausa = 0x6168 // "ha"
+	Hausa eLanguage = 0x6168 // "ha"
 	/// <summary>Hawaiian</summary>
-	Hawaiian = 0x776168 // "haw"
+	Hawaiian eLanguage = 0x776168 // "haw"
 	/// <summary>Hebrew</summary>
-	Hebrew = 0x7769 // "iw"
+	Hebrew eLanguage = 0x7769 // "iw"
 	/// <summary>Hindi</summary>
-	Hindi = 0x6968 // "hi"
+	Hindi eLanguage = 0x6968 // "hi"
 	/// <summary>Hungarian</summary>
-	Hungarian = 0x7568 // "hu"
+	Hungarian eLanguage = 0x7568 // "hu"
 	/// <summary>Icelandic</summary>
-	Icelandic = 0x7369 // "is"
+	Icelandic eLanguage = 0x7369 // "is"
 	/// <summary>Indonesian</summary>
-	Indonesian = 0x6469 // "id"
+	Indonesian eLanguage = 0x6469 // "id"
 	/// <summary>Italian</summary>
-	Italian = 0x7469 // "it"
+	Italian eLanguage = 0x7469 // "it"
 	/// <summary>Japanese</summary>
-	Japanese = 0x616A // "ja"
+	Japanese eLanguage = 0x616A // "ja"
 	/// <summary>Javanese</summary>
-	Javanese = 0x776A // "jw"
+	Javanese eLanguage = 0x776A // "jw"
 	/// <summary>Kannada</summary>
-	Kannada = 0x6E6B // "kn"
+	Kannada eLanguage = 0x6E6B // "kn"
 	/// <summary>Kazakh</summary>
-	Kazakh = 0x6B6B // "kk"
+	Kazakh eLanguage = 0x6B6B // "kk"
 	/// <summary>Khmer</summary>
-	Khmer = 0x6D6B // "km"
+	Khmer eLanguage = 0x6D6B // "km"
 	/// <summary>Korean</summary>
-	Korean = 0x6F6B // "ko"
+	Korean eLanguage = 0x6F6B // "ko"
 	/// <summary>Lao</summary>
-	Lao = 0x6F6C // "lo"
+	Lao eLanguage = 0x6F6C // "lo"
 	/// <summary>Latin</summary>
-	Latin = 0x616C // "la"
+	Latin eLanguage = 0x616C // "la"
 	/// <summary>Latvian</summary>
-	Latvian = 0x766C // "lv"
+	Latvian eLanguage = 0x766C // "lv"
 	/// <summary>Lingala</summary>
-	Lingala = 0x6E6C // "ln"
+	Lingala eLanguage = 0x6E6C // "ln"
 	/// <summary>Lithuanian</summary>
-	Lithuanian = 0x746C // "lt"
+	Lithuanian eLanguage = 0x746C // "lt"
 	/// <summary>Luxembourgish</summary>
-	Luxembourgish = 0x626C // "lb"
+	Luxembourgish eLanguage = 0x626C // "lb"
 	/// <summary>Macedonian</summary>
-	Macedonian = 0x6B6D // "mk"
+	Macedonian eLanguage = 0x6B6D // "mk"
 	/// <summary>Malagasy</summary>
-	Malagasy = 0x676D // "mg"
+	Malagasy eLanguage = 0x676D // "mg"
 	/// <summary>Malay</summary>
-	Malay = 0x736D // "ms"
+	Malay eLanguage = 0x736D // "ms"
 	/// <summary>Malayalam</summary>
-	Malayalam = 0x6C6D // "ml"
+	Malayalam eLanguage = 0x6C6D // "ml"
 	/// <summary>Maltese</summary>
-	Maltese = 0x746D // "mt"
+	Maltese eLanguage = 0x746D // "mt"
 	/// <summary>Maori</summary>
-	Maori = 0x696D // "mi"
+	Maori eLanguage = 0x696D // "mi"
 	/// <summary>Marathi</summary>
-	Marathi = 0x726D // "mr"
+	Marathi eLanguage = 0x726D // "mr"
 	/// <summary>Mongolian</summary>
-	Mongolian = 0x6E6D // "mn"
+	Mongolian eLanguage = 0x6E6D // "mn"
 	/// <summary>Myanmar</summary>
-	Myanmar = 0x796D // "my"
+	Myanmar eLanguage = 0x796D // "my"
 	/// <summary>Nepali</summary>
-	Nepali = 0x656E // "ne"
+	Nepali eLanguage = 0x656E // "ne"
 	/// <summary>Norwegian</summary>
-	Norwegian = 0x6F6E // "no"
+	Norwegian eLanguage = 0x6F6E // "no"
 	/// <summary>Nynorsk</summary>
-	Nynorsk = 0x6E6E // "nn"
+	Nynorsk eLanguage = 0x6E6E // "nn"
 	/// <summary>Occitan</summary>
-	Occitan = 0x636F // "oc"
+	Occitan eLanguage = 0x636F // "oc"
 	/// <summary>Pashto</summary>
-	Pashto = 0x7370 // "ps"
+	Pashto eLanguage = 0x7370 // "ps"
 	/// <summary>Persian</summary>
-	Persian = 0x6166 // "fa"
+	Persian eLanguage = 0x6166 // "fa"
 	/// <summary>Polish</summary>
-	Polish = 0x6C70 // "pl"
+	Polish eLanguage = 0x6C70 // "pl"
 	/// <summary>Portuguese</summary>
-	Portuguese = 0x7470 // "pt"
+	Portuguese eLanguage = 0x7470 // "pt"
 	/// <summary>Punjabi</summary>
-	Punjabi = 0x6170 // "pa"
+	Punjabi eLanguage = 0x6170 // "pa"
 	/// <summary>Romanian</summary>
-	Romanian = 0x6F72 // "ro"
+	Romanian eLanguage = 0x6F72 // "ro"
 	/// <summary>Russian</summary>
-	Russian = 0x7572 // "ru"
+	Russian eLanguage = 0x7572 // "ru"
 	/// <summary>Sanskrit</summary>
-	Sanskrit = 0x6173 // "sa"
+	Sanskrit eLanguage = 0x6173 // "sa"
 	/// <summary>Serbian</summary>
-	Serbian = 0x7273 // "sr"
+	Serbian eLanguage = 0x7273 // "sr"
 	/// <summary>Shona</summary>
-	Shona = 0x6E73 // "sn"
+	Shona eLanguage = 0x6E73 // "sn"
 	/// <summary>Sindhi</summary>
-	Sindhi = 0x6473 // "sd"
+	Sindhi eLanguage = 0x6473 // "sd"
 	/// <summary>Sinhala</summary>
-	Sinhala = 0x6973 // "si"
+	Sinhala eLanguage = 0x6973 // "si"
 	/// <summary>Slovak</summary>
-	Slovak = 0x6B73 // "sk"
+	Slovak eLanguage = 0x6B73 // "sk"
 	/// <summary>Slovenian</summary>
-	Slovenian = 0x6C73 // "sl"
+	Slovenian eLanguage = 0x6C73 // "sl"
 	/// <summary>Somali</summary>
-	Somali = 0x6F73 // "so"
+	Somali eLanguage = 0x6F73 // "so"
 	/// <summary>Spanish</summary>
-	Spanish = 0x7365 // "es"
+	Spanish eLanguage = 0x7365 // "es"
 	/// <summary>Sundanese</summary>
-	Sundanese = 0x7573 // "su"
+	Sundanese eLanguage = 0x7573 // "su"
 	/// <summary>Swahili</summary>
-	Swahili = 0x7773 // "sw"
+	Swahili eLanguage = 0x7773 // "sw"
 	/// <summary>Swedish</summary>
-	Swedish = 0x7673 // "sv"
+	Swedish eLanguage = 0x7673 // "sv"
 	/// <summary>Tagalog</summary>
-	Tagalog = 0x6C74 // "tl"
+	Tagalog eLanguage = 0x6C74 // "tl"
 	/// <summary>Tajik</summary>
-	Tajik = 0x6774 // "tg"
+	Tajik eLanguage = 0x6774 // "tg"
 	/// <summary>Tamil</summary>
-	Tamil = 0x6174 // "ta"
+	Tamil eLanguage = 0x6174 // "ta"
 	/// <summary>Tatar</summary>
-	Tatar = 0x7474 // "tt"
+	Tatar eLanguage = 0x7474 // "tt"
 	/// <summary>Telugu</summary>
-	Telugu = 0x6574 // "te"
+	Telugu eLanguage = 0x6574 // "te"
 	/// <summary>Thai</summary>
-	Thai = 0x6874 // "th"
+	Thai eLanguage = 0x6874 // "th"
 	/// <summary>Tibetan</summary>
-	Tibetan = 0x6F62 // "bo"
+	Tibetan eLanguage = 0x6F62 // "bo"
 	/// <summary>Turkish</summary>
-	Turkish = 0x7274 // "tr"
+	Turkish eLanguage = 0x7274 // "tr"
 	/// <summary>Turkmen</summary>
-	Turkmen = 0x6B74 // "tk"
+	Turkmen eLanguage = 0x6B74 // "tk"
 	/// <summary>Ukrainian</summary>
-	Ukrainian = 0x6B75 // "uk"
+	Ukrainian eLanguage = 0x6B75 // "uk"
 	/// <summary>Urdu</summary>
-	Urdu = 0x7275 // "ur"
+	Urdu eLanguage = 0x7275 // "ur"
 	/// <summary>Uzbek</summary>
-	Uzbek = 0x7A75 // "uz"
+	Uzbek eLanguage = 0x7A75 // "uz"
 	/// <summary>Vietnamese</summary>
-	Vietnamese = 0x6976 // "vi"
+	Vietnamese eLanguage = 0x6976 // "vi"
 	/// <summary>Welsh</summary>
-	Welsh = 0x7963 // "cy"
+	Welsh eLanguage = 0x7963 // "cy"
 	/// <summary>Yiddish</summary>
-	Yiddish = 0x6979 // "yi"
+	Yiddish eLanguage = 0x6979 // "yi"
 	/// <summary>Yoruba</summary>
-	Yoruba = 0x6F79 // "yo"
+	Yoruba eLanguage = 0x6F79 // "yo"
 )
+
+// The language key is the whisper language code, one ASCII character per byte starting from the lowest byte.
+// Whisper uses a few codes which are not ISO 639-1: "iw" for Hebrew, "jw" for Javanese, and "haw" for Hawaiian.
+
+type languageInfo struct {
+	lang    eLanguage
+	name    string
+	iso6391 string // empty when the language has no ISO 639-1 code
+	iso6393 string
+	iso6392 string // ISO 639-2/B, only when it differs from ISO 639-3
+}
+
+var languages = []languageInfo{
+	{Afrikaans, "Afrikaans", "af", "afr", ""},
+	{Albanian, "Albanian", "sq", "sqi", "alb"},
+	{Amharic, "Amharic", "am", "amh", ""},
+	{Arabic, "Arabic", "ar", "ara", ""},
+	{Armenian, "Armenian", "hy", "hye", "arm"},
+	{Assamese, "Assamese", "as", "asm", ""},
+	{Azerbaijani, "Azerbaijani", "az", "aze", ""},
+	{Bashkir, "Bashkir", "ba", "bak", ""},
+	{Basque, "Basque", "eu", "eus", "baq"},
+	{Belarusian, "Belarusian", "be", "bel", ""},
+	{Bengali, "Bengali", "bn", "ben", ""},
+	{Bosnian, "Bosnian", "bs", "bos", ""},
+	{Breton, "Breton", "br", "bre", ""},
+	{Bulgarian, "Bulgarian", "bg", "bul", ""},
+	{Catalan, "Catalan", "ca", "cat", ""},
+	{Chinese, "Chinese", "zh", "zho", "chi"},
+	{Croatian, "Croatian", "hr", "hrv", ""},
+	{Czech, "Czech", "cs", "ces", "cze"},
+	{Danish, "Danish", "da", "dan", ""},
+	{Dutch, "Dutch", "nl", "nld", "dut"},
+	{English, "English", "en", "eng", ""},
+	{Estonian, "Estonian", "et", "est", ""},
+	{Faroese, "Faroese", "fo", "fao", ""},
+	{Finnish, "Finnish", "fi", "fin", ""},
+	{French, "French", "fr", "fra", "fre"},
+	{Galician, "Galician", "gl", "glg", ""},
+	{Georgian, "Georgian", "ka", "kat", "geo"},
+	{German, "German", "de", "deu", "ger"},
+	{Greek, "Greek", "el", "ell", "gre"},
+	{Gujarati, "Gujarati", "gu", "guj", ""},
+	{HaitianCreole, "Haitian Creole", "ht", "hat", ""},
+	{Hausa, "Hausa", "ha", "hau", ""},
+	{Hawaiian, "Hawaiian", "", "haw", ""},
+	{Hebrew, "Hebrew", "he", "heb", ""},
+	{Hindi, "Hindi", "hi", "hin", ""},
+	{Hungarian, "Hungarian", "hu", "hun", ""},
+	{Icelandic, "Icelandic", "is", "isl", "ice"},
+	{Indonesian, "Indonesian", "id", "ind", ""},
+	{Italian, "Italian", "it", "ita", ""},
+	{Japanese, "Japanese", "ja", "jpn", ""},
+	{Javanese, "Javanese", "jv", "jav", ""},
+	{Kannada, "Kannada", "kn", "kan", ""},
+	{Kazakh, "Kazakh", "kk", "kaz", ""},
+	{Khmer, "Khmer", "km", "khm", ""},
+	{Korean, "Korean", "ko", "kor", ""},
+	{Lao, "Lao", "lo", "lao", ""},
+	{Latin, "Latin", "la", "lat", ""},
+	{Latvian, "Latvian", "lv", "lav", ""},
+	{Lingala, "Lingala", "ln", "lin", ""},
+	{Lithuanian, "Lithuanian", "lt", "lit", ""},
+	{Luxembourgish, "Luxembourgish", "lb", "ltz", ""},
+	{Macedonian, "Macedonian", "mk", "mkd", "mac"},
+	{Malagasy, "Malagasy", "mg", "mlg", ""},
+	{Malay, "Malay", "ms", "msa", "may"},
+	{Malayalam, "Malayalam", "ml", "mal", ""},
+	{Maltese, "Maltese", "mt", "mlt", ""},
+	{Maori, "Maori", "mi", "mri", "mao"},
+	{Marathi, "Marathi", "mr", "mar", ""},
+	{Mongolian, "Mongolian", "mn", "mon", ""},
+	{Myanmar, "Myanmar", "my", "mya", "bur"},
+	{Nepali, "Nepali", "ne", "nep", ""},
+	{Norwegian, "Norwegian", "no", "nor", ""},
+	{Nynorsk, "Nynorsk", "nn", "nno", ""},
+	{Occitan, "Occitan", "oc", "oci", ""},
+	{Pashto, "Pashto", "ps", "pus", ""},
+	{Persian, "Persian", "fa", "fas", "per"},
+	{Polish, "Polish", "pl", "pol", ""},
+	{Portuguese, "Portuguese", "pt", "por", ""},
+	{Punjabi, "Punjabi", "pa", "pan", ""},
+	{Romanian, "Romanian", "ro", "ron", "rum"},
+	{Russian, "Russian", "ru", "rus", ""},
+	{Sanskrit, "Sanskrit", "sa", "san", ""},
+	{Serbian, "Serbian", "sr", "srp", ""},
+	{Shona, "Shona", "sn", "sna", ""},
+	{Sindhi, "Sindhi", "sd", "snd", ""},
+	{Sinhala, "Sinhala", "si", "sin", ""},
+	{Slovak, "Slovak", "sk", "slk", "slo"},
+	{Slovenian, "Slovenian", "sl", "slv", ""},
+	{Somali, "Somali", "so", "som", ""},
+	{Spanish, "Spanish", "es", "spa", ""},
+	{Sundanese, "Sundanese", "su", "sun", ""},
+	{Swahili, "Swahili", "sw", "swa", ""},
+	{Swedish, "Swedish", "sv", "swe", ""},
+	{Tagalog, "Tagalog", "tl", "tgl", ""},
+	{Tajik, "Tajik", "tg", "tgk", ""},
+	{Tamil, "Tamil", "ta", "tam", ""},
+	{Tatar, "Tatar", "tt", "tat", ""},
+	{Telugu, "Telugu", "te", "tel", ""},
+	{Thai, "Thai", "th", "tha", ""},
+	{Tibetan, "Tibetan", "bo", "bod", "tib"},
+	{Turkish, "Turkish", "tr", "tur", ""},
+	{Turkmen, "Turkmen", "tk", "tuk", ""},
+	{Ukrainian, "Ukrainian", "uk", "ukr", ""},
+	{Urdu, "Urdu", "ur", "urd", ""},
+	{Uzbek, "Uzbek", "uz", "uzb", ""},
+	{Vietnamese, "Vietnamese", "vi", "vie", ""},
+	{Welsh, "Welsh", "cy", "cym", "wel"},
+	{Yiddish, "Yiddish", "yi", "yid", ""},
+	{Yoruba, "Yoruba", "yo", "yor", ""},
+}
+
+// languageIndex maps lower case codes and names to the index in languages.
+// A key of two languages would make one of them unreachable, so it panics when the table is built.
+var languageIndex = func() map[string]int {
+	index := make(map[string]int, len(languages)*5)
+	for i, info := range languages {
+		for _, key := range []string{info.lang.Code(), info.iso6391, info.iso6393, info.iso6392, normaliseLanguageName(info.name)} {
+			if key == "" {
+				continue
+			}
+			if existing, ok := index[key]; ok && existing != i {
+				panic(fmt.Sprintf("whisper: language key %q is both %s and %s", key, languages[existing].name, info.name))
+			}
+			index[key] = i
+		}
+	}
+	return index
+}()
+
+func normaliseLanguageName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+func (this eLanguage) info() *languageInfo {
+	for i := range languages {
+		if languages[i].lang == this {
+			return &languages[i]
+		}
+	}
+	return nil
+}
+
+// ParseLanguage accepts a whisper language code ("de", "iw"), an ISO 639-1, 639-2/B or 639-3 code ("he", "ger", "deu"),
+// an English name ("German", "haitian creole"), or "auto". Matching is case insensitive,
+// and a region or script suffix is ignored, e.g. "pt-BR" or "zh_Hant".
+func ParseLanguage(text string) (eLanguage, error) {
+	key := strings.ToLower(strings.TrimSpace(text))
+	if key == "auto" {
+		return Auto, nil
+	}
+
+	if i, ok := languageIndex[normaliseLanguageName(key)]; ok {
+		return languages[i].lang, nil
+	}
+	if cut := strings.IndexAny(key, "-_"); cut > 0 {
+		if i, ok := languageIndex[key[:cut]]; ok {
+			return languages[i].lang, nil
+		}
+	}
+
+	return Auto, fmt.Errorf("unknown language %q", text)
+}
+
+// SupportedLanguages returns every language whisper supports, sorted by English name. Auto is not included.
+func SupportedLanguages() []eLanguage {
+	result := make([]eLanguage, len(languages))
+	for i := range languages {
+		result[i] = languages[i].lang
+	}
+	return result
+}
+
+// Code returns the whisper language code, "auto" for Auto, or an empty string for an unknown language
+func (this eLanguage) Code() string {
+	if this == Auto {
+		return "auto"
+	}
+	if !this.isKey() {
+		return ""
+	}
+
+	var sb strings.Builder
+	for key := uint32(this); key != 0; key >>= 8 {
+		sb.WriteByte(byte(key))
+	}
+	return sb.String()
+}
+
+// Name returns the English name of the language, "Auto" for Auto, or an empty string for an unknown language
+func (this eLanguage) Name() string {
+	if this == Auto {
+		return "Auto"
+	}
+	if info := this.info(); info != nil {
+		return info.name
+	}
+	return ""
+}
+
+// ISO6391 returns the ISO 639-1 code, empty for Auto and for languages without one
+func (this eLanguage) ISO6391() string {
+	if info := this.info(); info != nil {
+		return info.iso6391
+	}
+	return ""
+}
+
+// ISO6393 returns the ISO 639-3 code, empty for Auto
+func (this eLanguage) ISO6393() string {
+	if info := this.info(); info != nil {
+		return info.iso6393
+	}
+	return ""
+}
+
+func (this eLanguage) String() string {
+	if name := this.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("eLanguage(0x%X)", int32(this))
+}
+
+// MarshalText writes the whisper language code
+func (this eLanguage) MarshalText() ([]byte, error) {
+	if !this.isValid() {
+		return nil, fmt.Errorf("cannot marshal unknown language 0x%X", int32(this))
+	}
+	return []byte(this.Code()), nil
+}
+
+// UnmarshalText accepts anything ParseLanguage does
+func (this *eLanguage) UnmarshalText(text []byte) error {
+	lang, err := ParseLanguage(string(text))
+	if err != nil {
+		return err
+	}
+	*this = lang
+	return nil
+}
+
+// isValid is true for Auto, and for the supported languages
+func (this eLanguage) isValid() bool {
+	return this == Auto || this.info() != nil
+}
+
+// isKey is true for keys made of 2 or 3 lower case ASCII letters
+func (this eLanguage) isKey() bool {
+	if this <= 0xFF || this > 0xFFFFFF {
+		return false
+	}
+
+	for key := uint32(this); key != 0; key >>= 8 {
+		if c := byte(key); c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
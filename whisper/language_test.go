@@ -0,0 +1,112 @@
+package whisper
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLanguage(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected eLanguage
+	}{
+		{"de", German},
+		{"DE", German},
+		{" German ", German},
+		{"ger", German},
+		{"deu", German},
+		{"iw", Hebrew},
+		{"he", Hebrew},
+		{"heb", Hebrew},
+		{"haitian creole", HaitianCreole},
+		{"HaitianCreole", HaitianCreole},
+		{"haw", Hawaiian},
+		{"jw", Javanese},
+		{"jv", Javanese},
+		{"pt-BR", Portuguese},
+		{"zh_Hant", Chinese},
+		{"auto", Auto},
+		{"AUTO", Auto},
+	}
+	for _, c := range cases {
+		lang, err := ParseLanguage(c.text)
+		if err != nil || lang != c.expected {
+			t.Errorf("ParseLanguage(%q) = %v, %v, expected %v", c.text, lang, err, c.expected)
+		}
+	}
+
+	for _, text := range []string{"", "xx", "klingon", "-de", "en english"} {
+		if lang, err := ParseLanguage(text); err == nil {
+			t.Errorf("ParseLanguage(%q) = %v", text, lang)
+		}
+	}
+}
+
+// Every key of every language finds that language, so no key was overwritten by another language
+func TestLanguageKeys(t *testing.T) {
+	for _, lang := range SupportedLanguages() {
+		info := lang.info()
+		for _, key := range []string{lang.Code(), info.iso6391, info.iso6393, info.iso6392, info.name} {
+			if key == "" {
+				continue
+			}
+			if got, err := ParseLanguage(key); err != nil || got != lang {
+				t.Errorf("%q of %s parses to %v, %v", key, info.name, got, err)
+			}
+		}
+		if !lang.isKey() {
+			t.Errorf("%s: 0x%X is not a language key", info.name, int32(lang))
+		}
+	}
+}
+
+func TestLanguageAccessors(t *testing.T) {
+	if German.Code() != "de" || German.Name() != "German" || German.ISO6391() != "de" || German.ISO6393() != "deu" {
+		t.Errorf("German: %q %q %q %q", German.Code(), German.Name(), German.ISO6391(), German.ISO6393())
+	}
+	if Hawaiian.ISO6391() != "" || Hawaiian.ISO6393() != "haw" || Hawaiian.Code() != "haw" {
+		t.Errorf("Hawaiian: %q %q %q", Hawaiian.Code(), Hawaiian.ISO6391(), Hawaiian.ISO6393())
+	}
+	if Auto.Code() != "auto" || Auto.Name() != "Auto" || Auto.ISO6391() != "" {
+		t.Errorf("Auto: %q %q %q", Auto.Code(), Auto.Name(), Auto.ISO6391())
+	}
+
+	unknown := eLanguage(0x7A7A) // "zz"
+	if unknown.Name() != "" || unknown.isValid() {
+		t.Errorf("%q is valid", unknown.Name())
+	}
+	if unknown.String() != "eLanguage(0x7A7A)" {
+		t.Errorf("String() = %q", unknown.String())
+	}
+	if eLanguage(0x41).Code() != "" {
+		t.Error("a single byte has a code")
+	}
+}
+
+func TestLanguageText(t *testing.T) {
+	type settings struct {
+		Language eLanguage `json:"language"`
+	}
+
+	data, err := json.Marshal(settings{Language: HaitianCreole})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"language":"ht"}` {
+		t.Fatalf("marshalled %s", data)
+	}
+
+	var decoded settings
+	if err := json.Unmarshal([]byte(`{"language":"French"}`), &decoded); err != nil || decoded.Language != French {
+		t.Fatalf("unmarshalled %v, %v", decoded.Language, err)
+	}
+	if err := json.Unmarshal([]byte(`{"language":"auto"}`), &decoded); err != nil || decoded.Language != Auto {
+		t.Fatalf("unmarshalled %v, %v", decoded.Language, err)
+	}
+	if err := json.Unmarshal([]byte(`{"language":"xx"}`), &decoded); err == nil {
+		t.Fatal("an unknown language was unmarshalled")
+	}
+	if _, err := json.Marshal(settings{Language: eLanguage(0x7A7A)}); err == nil {
+		t.Fatal("an unknown language was marshalled")
+	}
+}
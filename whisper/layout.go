@@ -0,0 +1,301 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// The Go mirrors of the native structs must match the C++ layout exactly, or the DLL reads and writes the wrong fields.
+// The expected layouts below are written out by hand from the C++ headers, x64 only since that's the only DLL published,
+// and CheckLayouts compares them with what the Go compiler produced. It doesn't call into the DLL.
+
+type fieldLayout struct {
+	name   string
+	offset uintptr
+	size   uintptr
+}
+
+type structLayout struct {
+	name   string
+	size   uintptr
+	fields []fieldLayout
+}
+
+// abiLayout is the layout of the native structs, DLL versions pick their variant with Capabilities.ParamsLayout
+type abiLayout struct {
+	variant int
+	structs []structLayout
+}
+
+var abiLayouts = []abiLayout{
+	{variant: 1, structs: []structLayout{
+		// Whisper/API/sFullParams.h
+		{"sFullParams", 112, []fieldLayout{
+			{"strategy", 0, 4},
+			{"cpuThreads", 4, 4},
+			{"n_max_text_ctx", 8, 4},
+			{"offset_ms", 12, 4},
+			{"duration_ms", 16, 4},
+			{"flags", 20, 4},
+			{"language", 24, 4},
+			{"thold_pt", 28, 4},
+			{"thold_ptsum", 32, 4},
+			{"max_len", 36, 4},
+			{"max_tokens", 40, 4},
+			{"greedy.n_past", 44, 4},
+			{"beam_search.n_past", 48, 4},
+			{"beam_search.beam_width", 52, 4},
+			{"beam_search.n_best", 56, 4},
+			{"audio_ctx", 60, 4},
+			{"prompt_tokens", 64, 8},
+			{"prompt_n_tokens", 72, 4},
+			{"new_segment_callback", 80, 8},
+			{"new_segment_callback_user_data", 88, 8},
+			{"encoder_begin_callback", 96, 8},
+			{"encoder_begin_callback_user_data", 104, 8},
+		}},
+
+		// Whisper/API/sModelSetup.h
+		{"sModelSetup", 16, []fieldLayout{
+			{"impl", 0, 4},
+			{"flags", 4, 4},
+			{"adapter", 8, 8},
+		}},
+
+		// Whisper/API/TranscribeStructs.h
+		{"sSegment", 32, []fieldLayout{
+			{"text", 0, 8},
+			{"time.begin", 8, 8},
+			{"time.end", 16, 8},
+			{"firstToken", 24, 4},
+			{"countTokens", 28, 4},
+		}},
+		{"sToken", 48, []fieldLayout{
+			{"text", 0, 8},
+			{"time.begin", 8, 8},
+			{"time.end", 16, 8},
+			{"probability", 24, 4},
+			{"probabilityTimestamp", 28, 4},
+			{"ptsum", 32, 4},
+			{"vlen", 36, 4},
+			{"id", 40, 4},
+			{"flags", 44, 4},
+		}},
+		{"sTranscribeLength", 8, []fieldLayout{
+			{"countSegments", 0, 4},
+			{"countTokens", 4, 4},
+		}},
+
+		// Whisper/API/loggerApi.h
+		{"sLoggerSetup", 24, []fieldLayout{
+			{"sink", 0, 8},
+			{"context", 8, 8},
+			{"level", 16, 1},
+			{"flags", 17, 1},
+		}},
+
+		// Whisper/API/iMediaFoundation.h
+		{"sCaptureParams", 20, []fieldLayout{
+			{"minDuration", 0, 4},
+			{"maxDuration", 4, 4},
+			{"dropStartSilence", 8, 4},
+			{"pauseDuration", 12, 4},
+			{"flags", 16, 4},
+		}},
+		{"sCaptureDevice", 16, []fieldLayout{
+			{"displayName", 0, 8},
+			{"endpoint", 8, 8},
+		}},
+
+		// Whisper/API/sFullParams.h
+		{"sCaptureCallbacks", 24, []fieldLayout{
+			{"shouldCancel", 0, 8},
+			{"captureStatus", 8, 8},
+			{"pv", 16, 8},
+		}},
+	}},
+}
+
+// layoutFor returns the layouts of the variant of the version, nil when the version is too old
+func layoutFor(ver WinVersion) *abiLayout {
+	caps, ok := CapabilitiesFor(ver)
+	if !ok {
+		return nil
+	}
+	for i := range abiLayouts {
+		if abiLayouts[i].variant == caps.ParamsLayout {
+			return &abiLayouts[i]
+		}
+	}
+	return nil
+}
+
+// goLayouts measures the Go mirrors of the native structs
+func goLayouts() []structLayout {
+	field := func(name string, offset, size uintptr) fieldLayout {
+		return fieldLayout{name, offset, size}
+	}
+
+	var fp _FullParams
+	var ms _sModelSetup
+	var seg sSegment
+	var tok SToken
+	var tl sTranscribeLength
+	var ls sLoggerSetup
+	var cp sCaptureParams
+	var cd sCaptureDevice
+	var cc sCaptureCallbacks
+
+	return []structLayout{
+		{"sFullParams", unsafe.Sizeof(fp), []fieldLayout{
+			field("strategy", unsafe.Offsetof(fp.strategy), unsafe.Sizeof(fp.strategy)),
+			field("cpuThreads", unsafe.Offsetof(fp.cpuThreads), unsafe.Sizeof(fp.cpuThreads)),
+			field("n_max_text_ctx", unsafe.Offsetof(fp.n_max_text_ctx), unsafe.Sizeof(fp.n_max_text_ctx)),
+			field("offset_ms", unsafe.Offsetof(fp.offset_ms), unsafe.Sizeof(fp.offset_ms)),
+			field("duration_ms", unsafe.Offsetof(fp.duration_ms), unsafe.Sizeof(fp.duration_ms)),
+			field("flags", unsafe.Offsetof(fp.Flags), unsafe.Sizeof(fp.Flags)),
+			field("language", unsafe.Offsetof(fp.Language), unsafe.Sizeof(fp.Language)),
+			field("thold_pt", unsafe.Offsetof(fp.thold_pt), unsafe.Sizeof(fp.thold_pt)),
+			field("thold_ptsum", unsafe.Offsetof(fp.thold_ptsum), unsafe.Sizeof(fp.thold_ptsum)),
+			field("max_len", unsafe.Offsetof(fp.max_len), unsafe.Sizeof(fp.max_len)),
+			field("max_tokens", unsafe.Offsetof(fp.max_tokens), unsafe.Sizeof(fp.max_tokens)),
+			field("greedy.n_past", unsafe.Offsetof(fp.greedy)+unsafe.Offsetof(fp.greedy.n_past), unsafe.Sizeof(fp.greedy.n_past)),
+			field("beam_search.n_past", unsafe.Offsetof(fp.beam_search)+unsafe.Offsetof(fp.beam_search.n_past), unsafe.Sizeof(fp.beam_search.n_past)),
+			field("beam_search.beam_width", unsafe.Offsetof(fp.beam_search)+unsafe.Offsetof(fp.beam_search.beam_width), unsafe.Sizeof(fp.beam_search.beam_width)),
+			field("beam_search.n_best", unsafe.Offsetof(fp.beam_search)+unsafe.Offsetof(fp.beam_search.n_best), unsafe.Sizeof(fp.beam_search.n_best)),
+			field("audio_ctx", unsafe.Offsetof(fp.audio_ctx), unsafe.Sizeof(fp.audio_ctx)),
+			field("prompt_tokens", unsafe.Offsetof(fp.prompt_tokens), unsafe.Sizeof(fp.prompt_tokens)),
+			field("prompt_n_tokens", unsafe.Offsetof(fp.prompt_n_tokens), unsafe.Sizeof(fp.prompt_n_tokens)),
+			field("new_segment_callback", unsafe.Offsetof(fp.new_segment_callback), unsafe.Sizeof(fp.new_segment_callback)),
+			field("new_segment_callback_user_data", unsafe.Offsetof(fp.new_segment_callback_user_data), unsafe.Sizeof(fp.new_segment_callback_user_data)),
+			field("encoder_begin_callback", unsafe.Offsetof(fp.encoder_begin_callback), unsafe.Sizeof(fp.encoder_begin_callback)),
+			field("encoder_begin_callback_user_data", unsafe.Offsetof(fp.encoder_begin_callback_user_data), unsafe.Sizeof(fp.encoder_begin_callback_user_data)),
+		}},
+
+		{"sModelSetup", unsafe.Sizeof(ms), []fieldLayout{
+			field("impl", unsafe.Offsetof(ms.impl), unsafe.Sizeof(ms.impl)),
+			field("flags", unsafe.Offsetof(ms.flags), unsafe.Sizeof(ms.flags)),
+			field("adapter", unsafe.Offsetof(ms.adapter), unsafe.Sizeof(ms.adapter)),
+		}},
+
+		{"sSegment", unsafe.Sizeof(seg), []fieldLayout{
+			field("text", unsafe.Offsetof(seg.text), unsafe.Sizeof(seg.text)),
+			field("time.begin", unsafe.Offsetof(seg.Time)+unsafe.Offsetof(seg.Time.Begin), unsafe.Sizeof(seg.Time.Begin)),
+			field("time.end", unsafe.Offsetof(seg.Time)+unsafe.Offsetof(seg.Time.End), unsafe.Sizeof(seg.Time.End)),
+			field("firstToken", unsafe.Offsetof(seg.FirstToken), unsafe.Sizeof(seg.FirstToken)),
+			field("countTokens", unsafe.Offsetof(seg.CountTokens), unsafe.Sizeof(seg.CountTokens)),
+		}},
+
+		{"sToken", unsafe.Sizeof(tok), []fieldLayout{
+			field("text", unsafe.Offsetof(tok.text), unsafe.Sizeof(tok.text)),
+			field("time.begin", unsafe.Offsetof(tok.Time)+unsafe.Offsetof(tok.Time.Begin), unsafe.Sizeof(tok.Time.Begin)),
+			field("time.end", unsafe.Offsetof(tok.Time)+unsafe.Offsetof(tok.Time.End), unsafe.Sizeof(tok.Time.End)),
+			field("probability", unsafe.Offsetof(tok.Probability), unsafe.Sizeof(tok.Probability)),
+			field("probabilityTimestamp", unsafe.Offsetof(tok.ProbabilityTimestamp), unsafe.Sizeof(tok.ProbabilityTimestamp)),
+			field("ptsum", unsafe.Offsetof(tok.Ptsum), unsafe.Sizeof(tok.Ptsum)),
+			field("vlen", unsafe.Offsetof(tok.Vlen), unsafe.Sizeof(tok.Vlen)),
+			field("id", unsafe.Offsetof(tok.Id), unsafe.Sizeof(tok.Id)),
+			field("flags", unsafe.Offsetof(tok.Flags), unsafe.Sizeof(tok.Flags)),
+		}},
+
+		{"sTranscribeLength", unsafe.Sizeof(tl), []fieldLayout{
+			field("countSegments", unsafe.Offsetof(tl.CountSegments), unsafe.Sizeof(tl.CountSegments)),
+			field("countTokens", unsafe.Offsetof(tl.CountTokens), unsafe.Sizeof(tl.CountTokens)),
+		}},
+
+		{"sLoggerSetup", unsafe.Sizeof(ls), []fieldLayout{
+			field("sink", unsafe.Offsetof(ls.sink), unsafe.Sizeof(ls.sink)),
+			field("context", unsafe.Offsetof(ls.context), unsafe.Sizeof(ls.context)),
+			field("level", unsafe.Offsetof(ls.level), unsafe.Sizeof(ls.level)),
+			field("flags", unsafe.Offsetof(ls.flags), unsafe.Sizeof(ls.flags)),
+		}},
+
+		{"sCaptureParams", unsafe.Sizeof(cp), []fieldLayout{
+			field("minDuration", unsafe.Offsetof(cp.minDuration), unsafe.Sizeof(cp.minDuration)),
+			field("maxDuration", unsafe.Offsetof(cp.maxDuration), unsafe.Sizeof(cp.maxDuration)),
+			field("dropStartSilence", unsafe.Offsetof(cp.dropStartSilence), unsafe.Sizeof(cp.dropStartSilence)),
+			field("pauseDuration", unsafe.Offsetof(cp.pauseDuration), unsafe.Sizeof(cp.pauseDuration)),
+			field("flags", unsafe.Offsetof(cp.flags), unsafe.Sizeof(cp.flags)),
+		}},
+		{"sCaptureDevice", unsafe.Sizeof(cd), []fieldLayout{
+			field("displayName", unsafe.Offsetof(cd.displayName), unsafe.Sizeof(cd.displayName)),
+			field("endpoint", unsafe.Offsetof(cd.endpoint), unsafe.Sizeof(cd.endpoint)),
+		}},
+		{"sCaptureCallbacks", unsafe.Sizeof(cc), []fieldLayout{
+			field("shouldCancel", unsafe.Offsetof(cc.shouldCancel), unsafe.Sizeof(cc.shouldCancel)),
+			field("captureStatus", unsafe.Offsetof(cc.captureStatus), unsafe.Sizeof(cc.captureStatus)),
+			field("pv", unsafe.Offsetof(cc.pv), unsafe.Sizeof(cc.pv)),
+		}},
+	}
+}
+
+// CheckLayouts compares the Go mirrors of the native structs with the layout whisper.dll of that version expects.
+// Every difference is reported. It is pure Go, so it can run in the unit tests of any platform.
+func CheckLayouts(ver WinVersion) error {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		return errors.New("whisper.dll is only published for 64 bit Windows")
+	}
+
+	expected := layoutFor(ver)
+	if expected == nil {
+		return fmt.Errorf("no known struct layouts for whisper.dll version %s", ver)
+	}
+
+	return compareLayouts(expected.structs, goLayouts())
+}
+
+func compareLayouts(expected []structLayout, actual []structLayout) error {
+	var problems []string
+
+	for _, want := range expected {
+		got := findLayout(actual, want.name)
+		if got == nil {
+			problems = append(problems, want.name+": no Go mirror")
+			continue
+		}
+
+		if got.size != want.size {
+			problems = append(problems, fmt.Sprintf("%s: size is %d, expected %d", want.name, got.size, want.size))
+		}
+
+		for _, wantField := range want.fields {
+			gotField := findField(got.fields, wantField.name)
+			switch {
+			case gotField == nil:
+				problems = append(problems, fmt.Sprintf("%s.%s: missing from the Go mirror", want.name, wantField.name))
+			case gotField.offset != wantField.offset || gotField.size != wantField.size:
+				problems = append(problems, fmt.Sprintf("%s.%s: offset %d size %d, expected offset %d size %d",
+					want.name, wantField.name, gotField.offset, gotField.size, wantField.offset, wantField.size))
+			}
+		}
+
+		if len(got.fields) != len(want.fields) {
+			problems = append(problems, fmt.Sprintf("%s: the Go mirror describes %d fields, expected %d", want.name, len(got.fields), len(want.fields)))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("native struct layout mismatch:\n\t" + strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+func findLayout(layouts []structLayout, name string) *structLayout {
+	for i := range layouts {
+		if layouts[i].name == name {
+			return &layouts[i]
+		}
+	}
+	return nil
+}
+
+func findField(fields []fieldLayout, name string) *fieldLayout {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package whisper
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestLayoutsMatchVariant1(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("the layouts are those of 64 bit Windows")
+	}
+
+	layout := layoutFor(WinVersion{1, 10, 0, 0})
+	if layout == nil || layout.variant != 1 {
+		t.Fatalf("layoutFor(1.10) = %v, expected variant 1", layout)
+	}
+
+	if err := CheckLayouts(WinVersion{1, 10, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayoutMismatchReported(t *testing.T) {
+	// Copy variant 1, moving one field of sFullParams
+	var expected []structLayout
+	for _, s := range abiLayouts[0].structs {
+		s.fields = append([]fieldLayout(nil), s.fields...)
+		if s.name == "sFullParams" {
+			for i := range s.fields {
+				if s.fields[i].name == "audio_ctx" {
+					s.fields[i].offset += 4
+				}
+			}
+		}
+		expected = append(expected, s)
+	}
+
+	err := compareLayouts(expected, goLayouts())
+	if err == nil {
+		t.Fatal("the moved field was not reported")
+	}
+	if !strings.Contains(err.Error(), "sFullParams.audio_ctx") {
+		t.Fatalf("the error does not name the field: %s", err)
+	}
+	if strings.Count(err.Error(), "\n\t") != 1 {
+		t.Fatalf("expected only the moved field to be reported: %s", err)
+	}
+}
+
+func TestLayoutOldVersionRefused(t *testing.T) {
+	if err := CheckLayouts(WinVersion{1, 8, 0, 0}); err == nil {
+		t.Fatal("version 1.8 has no known layout")
+	}
+}
@@ -1,8 +1,10 @@
 package whisper
 
 import (
-	"C"
 	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 /*
@@ -14,17 +16,31 @@ type eLogLevel uint8
 
 const (
 	LlError   eLogLevel = 0
-	LlWarning           = 1
-	LlInfo              = 2
-	LlDebug             = 3
+	LlWarning eLogLevel = 1
+	LlInfo    eLogLevel = 2
+	LlDebug   eLogLevel = 3
 )
 
+func (this eLogLevel) String() string {
+	switch this {
+	case LlError:
+		return "error"
+	case LlWarning:
+		return "warning"
+	case LlInfo:
+		return "info"
+	case LlDebug:
+		return "debug"
+	}
+	return fmt.Sprintf("eLogLevel(%d)", uint8(this))
+}
+
 type eLogFlags uint8
 
 const (
 	LfNone              eLogFlags = 0
-	LfUseStandardError            = 1
-	LfSkipFormatMessage           = 2
+	LfUseStandardError  eLogFlags = 1
+	LfSkipFormatMessage eLogFlags = 2
 )
 
 type sLoggerSetup struct {
@@ -34,10 +50,49 @@ type sLoggerSetup struct {
 	flags   eLogFlags // eLoggerFlags
 }
 
-func fnLoggerSink(context uintptr, lvl eLogLevel, message *C.char) uintptr {
+// Logger receives the log messages of whisper.dll, from any thread
+type Logger interface {
+	Log(level eLogLevel, message string)
+}
+
+// LoggerFunc is a function used as a Logger
+type LoggerFunc func(level eLogLevel, message string)
+
+func (this LoggerFunc) Log(level eLogLevel, message string) {
+	this(level, message)
+}
+
+// The DLL has a single logger for the process, so the Go side is global too
+type loggerState struct {
+	logger Logger
+}
+
+var (
+	currentLogger atomic.Pointer[loggerState]
+	currentLevel  atomic.Uint32
+)
 
-	strmessage := C.GoString(message)
-	fmt.Printf("%d - %s\n", lvl, strmessage)
+func setLogger(logger Logger, level eLogLevel) {
+	currentLogger.Store(&loggerState{logger: logger})
+	currentLevel.Store(uint32(level))
+}
+
+// logMessage decodes the message of the DLL and passes it to the logger when the level is enabled
+func logMessage(level eLogLevel, message *byte) {
+	state := currentLogger.Load()
+	if state == nil || state.logger == nil || uint32(level) > currentLevel.Load() {
+		return
+	}
+
+	text := goString(message)
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "�")
+	}
+	state.logger.Log(level, strings.TrimRight(text, "\r\n"))
+}
 
+// void( __stdcall* pfnLoggerSink )( void* context, eLogLevel lvl, const char* message );
+func fnLoggerSink(context uintptr, lvl uintptr, message *byte) uintptr {
+	logMessage(eLogLevel(lvl), message)
 	return 0
 }
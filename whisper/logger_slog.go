@@ -0,0 +1,59 @@
+//go:build go1.21
+// +build go1.21
+
+package whisper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogLogger returns a Logger passing the messages of whisper.dll to the handler,
+// with the attribute component=whisper.dll
+func SlogLogger(handler slog.Handler) Logger {
+	return &slogLogger{handler: handler.WithAttrs([]slog.Attr{slog.String("component", DLLName)})}
+}
+
+type slogLogger struct {
+	handler slog.Handler
+}
+
+func (this *slogLogger) Log(level eLogLevel, message string) {
+	slevel := SlogLevel(level)
+	ctx := context.Background()
+	if !this.handler.Enabled(ctx, slevel) {
+		return
+	}
+
+	// The messages come from native code, so there is no Go caller to record
+	record := slog.NewRecord(time.Now(), slevel, message, 0)
+	this.handler.Handle(ctx, record)
+}
+
+// SlogLevel maps the level of a whisper.dll message to slog
+func SlogLevel(level eLogLevel) slog.Level {
+	switch level {
+	case LlError:
+		return slog.LevelError
+	case LlWarning:
+		return slog.LevelWarn
+	case LlInfo:
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// LogLevelFor returns the most verbose whisper.dll level the slog level lets through,
+// e.g. to set up the native logger with the level of the handler
+func LogLevelFor(level slog.Level) eLogLevel {
+	switch {
+	case level <= slog.LevelDebug:
+		return LlDebug
+	case level <= slog.LevelInfo:
+		return LlInfo
+	case level <= slog.LevelWarn:
+		return LlWarning
+	}
+	return LlError
+}
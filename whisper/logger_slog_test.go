@@ -0,0 +1,66 @@
+//go:build go1.21
+// +build go1.21
+
+package whisper
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		level eLogLevel
+		slog  slog.Level
+	}{
+		{LlError, slog.LevelError},
+		{LlWarning, slog.LevelWarn},
+		{LlInfo, slog.LevelInfo},
+		{LlDebug, slog.LevelDebug},
+	}
+	for _, test := range tests {
+		if got := SlogLevel(test.level); got != test.slog {
+			t.Errorf("SlogLevel(%v) = %v, expected %v", test.level, got, test.slog)
+		}
+		if got := LogLevelFor(test.slog); got != test.level {
+			t.Errorf("LogLevelFor(%v) = %v, expected %v", test.slog, got, test.level)
+		}
+	}
+
+	// The levels between the named ones round to the most verbose level let through
+	for level, expected := range map[slog.Level]eLogLevel{
+		slog.LevelDebug - 4: LlDebug,
+		slog.LevelInfo - 1:  LlInfo,
+		slog.LevelWarn + 1:  LlError,
+		slog.LevelError + 4: LlError,
+	} {
+		if got := LogLevelFor(level); got != expected {
+			t.Errorf("LogLevelFor(%v) = %v, expected %v", level, got, expected)
+		}
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := SlogLogger(handler)
+
+	logger.Log(LlWarning, "model loaded")
+	logger.Log(LlDebug, "filtered")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	expected := `level=WARN msg="model loaded" component=` + DLLName
+	if len(lines) != 1 || lines[0] != expected {
+		t.Fatalf("logged %q, expected %q", lines, expected)
+	}
+}
@@ -0,0 +1,103 @@
+package whisper
+
+import (
+	"testing"
+)
+
+type testLogEntry struct {
+	level   eLogLevel
+	message string
+}
+
+// testLogger installs a logger recording the messages up to level, and removes it when the test ends
+func testLogger(t *testing.T, level eLogLevel) *[]testLogEntry {
+	var entries []testLogEntry
+	setLogger(LoggerFunc(func(level eLogLevel, message string) {
+		entries = append(entries, testLogEntry{level, message})
+	}), level)
+	t.Cleanup(func() { setLogger(nil, LlError) })
+	return &entries
+}
+
+// cString returns the NUL terminated copy of s, as the DLL passes it
+func cString(s string) *byte {
+	return &append([]byte(s), 0)[0]
+}
+
+func TestLogMessageLevel(t *testing.T) {
+	entries := testLogger(t, LlWarning)
+
+	logMessage(LlError, cString("error"))
+	logMessage(LlWarning, cString("warning"))
+	logMessage(LlInfo, cString("info"))
+	logMessage(LlDebug, cString("debug"))
+
+	expected := []testLogEntry{{LlError, "error"}, {LlWarning, "warning"}}
+	if len(*entries) != len(expected) {
+		t.Fatalf("logged %v, expected %v", *entries, expected)
+	}
+	for i, e := range expected {
+		if (*entries)[i] != e {
+			t.Errorf("entry %d: %v, expected %v", i, (*entries)[i], e)
+		}
+	}
+}
+
+func TestLogMessageText(t *testing.T) {
+	entries := testLogger(t, LlDebug)
+
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"line\r\n", "line"},
+		{"lines\n\n", "lines"},
+		{"two\nlines\n", "two\nlines"},
+		{"bad \xff\xfe byte", "bad � byte"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		logMessage(LlInfo, cString(test.message))
+	}
+	if len(*entries) != len(tests) {
+		t.Fatalf("logged %v", *entries)
+	}
+	for i, test := range tests {
+		if got := (*entries)[i].message; got != test.expected {
+			t.Errorf("logMessage(%q) logged %q, expected %q", test.message, got, test.expected)
+		}
+	}
+}
+
+func TestLoggerSink(t *testing.T) {
+	entries := testLogger(t, LlDebug)
+
+	if r := fnLoggerSink(0, uintptr(LlDebug), cString("from the DLL\n")); r != 0 {
+		t.Errorf("fnLoggerSink returned %d", r)
+	}
+	if len(*entries) != 1 || (*entries)[0] != (testLogEntry{LlDebug, "from the DLL"}) {
+		t.Fatalf("logged %v", *entries)
+	}
+
+	// Without a logger the messages are dropped
+	setLogger(nil, LlDebug)
+	logMessage(LlError, cString("dropped"))
+	if len(*entries) != 1 {
+		t.Fatalf("logged %v without a logger", *entries)
+	}
+}
+
+func TestLogLevelString(t *testing.T) {
+	for level, expected := range map[eLogLevel]string{
+		LlError:      "error",
+		LlWarning:    "warning",
+		LlInfo:       "info",
+		LlDebug:      "debug",
+		eLogLevel(7): "eLogLevel(7)",
+	} {
+		if level.String() != expected {
+			t.Errorf("%d: %q, expected %q", uint8(level), level.String(), expected)
+		}
+	}
+}
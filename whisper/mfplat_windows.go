@@ -0,0 +1,201 @@
+package whisper
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The few Media Foundation objects the Go audio sources create, from mfplat.dll.
+// Media Foundation must be started, which IMediaFoundation does: see Libwhisper.InitMediaFoundation
+
+var (
+	mfplat                = windows.NewLazySystemDLL("mfplat.dll")
+	procMFCreateMediaType = mfplat.NewProc("MFCreateMediaType")
+	procMFCreateSample    = mfplat.NewProc("MFCreateSample")
+	procMFCreateMemBuffer = mfplat.NewProc("MFCreateMemoryBuffer")
+)
+
+// mfapi.h, mfidl.h, mfreadwrite.h
+var (
+	mfMTMajorType             = windows.GUID{Data1: 0x48eba18e, Data2: 0xf8c9, Data3: 0x4687, Data4: [8]byte{0xbf, 0x11, 0x0a, 0x74, 0xc9, 0xf9, 0x6a, 0x8f}}
+	mfMTSubtype               = windows.GUID{Data1: 0xf7e34c9a, Data2: 0x42e8, Data3: 0x4714, Data4: [8]byte{0xb7, 0x4b, 0xcb, 0x29, 0xd7, 0x2c, 0x35, 0xe5}}
+	mfMTAudioNumChannels      = windows.GUID{Data1: 0x37e48bf5, Data2: 0x645e, Data3: 0x4c5b, Data4: [8]byte{0x89, 0xde, 0xad, 0xa9, 0xe2, 0x9b, 0x69, 0x6a}}
+	mfMTAudioSamplesPerSecond = windows.GUID{Data1: 0x5faeeae7, Data2: 0x0290, Data3: 0x4c31, Data4: [8]byte{0x9e, 0x8a, 0xc5, 0x34, 0xf6, 0x8d, 0x9d, 0xba}}
+	mfMTAudioBitsPerSample    = windows.GUID{Data1: 0xf2deb57f, Data2: 0x40fa, Data3: 0x4764, Data4: [8]byte{0xaa, 0x33, 0xed, 0x4f, 0x2d, 0x1f, 0xf6, 0x69}}
+	mfMTAudioBlockAlignment   = windows.GUID{Data1: 0x322de230, Data2: 0x9eeb, Data3: 0x43bd, Data4: [8]byte{0xab, 0x7a, 0xff, 0x41, 0x22, 0x51, 0x54, 0x1d}}
+	mfMTAudioAvgBytesPerSec   = windows.GUID{Data1: 0x1aab75c8, Data2: 0xcfef, Data3: 0x451c, Data4: [8]byte{0xab, 0x95, 0xac, 0x03, 0x4b, 0x8e, 0x17, 0x31}}
+	mfMTAllSamplesIndependent = windows.GUID{Data1: 0xc9173739, Data2: 0x5e56, Data3: 0x461c, Data4: [8]byte{0xb7, 0x13, 0x46, 0xfb, 0x99, 0x5c, 0xb9, 0x5f}}
+	mfPDDuration              = windows.GUID{Data1: 0x6c990d33, Data2: 0xbb8e, Data3: 0x477a, Data4: [8]byte{0x85, 0x98, 0x0d, 0x5d, 0x96, 0xfc, 0xd8, 0x8a}}
+
+	mfMediaTypeAudio   = windows.GUID{Data1: 0x73647561, Data2: 0x0000, Data3: 0x0010, Data4: [8]byte{0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}}
+	mfAudioFormatFloat = windows.GUID{Data1: 0x00000003, Data2: 0x0000, Data3: 0x0010, Data4: [8]byte{0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}}
+
+	iidIMFSourceReader = windows.GUID{Data1: 0x70ae66f2, Data2: 0xc809, Data3: 0x4e4f, Data4: [8]byte{0x89, 0x15, 0xbd, 0xcb, 0x40, 0x6b, 0x79, 0x93}}
+)
+
+const (
+	mfSourceReaderFirstAudioStream = 0xFFFFFFFD
+	mfSourceReaderAllStreams       = 0xFFFFFFFE
+	mfSourceReaderMediaSource      = 0xFFFFFFFF
+
+	mfSourceReaderfEndOfStream = 0x2
+
+	mfEInvalidStreamNumber = 0xC00D36B3
+	mfEInvalidMediaType    = 0xC00D36B4
+	mfENoMoreTypes         = 0xC00D36B9
+	mfEUnsupportedService  = 0xC00D36BA
+	mfEAttributeNotFound   = 0xC00D36E6
+	eNotImpl               = 0x80004001
+	eFail                  = 0x80004005
+
+	vtUI8 = 21
+	vtI8  = 20
+)
+
+// Vtable indices, IMFAttributes is the base of IMFMediaType and IMFSample
+const (
+	imfAttributesGetUINT32 = 7
+	imfAttributesGetGUID   = 10
+	imfAttributesSetUINT32 = 21
+	imfAttributesSetGUID   = 24
+
+	imfSampleSetSampleTime     = 36
+	imfSampleSetSampleDuration = 38
+	imfSampleAddBuffer         = 42
+
+	imfMediaBufferLock             = 3
+	imfMediaBufferUnlock           = 4
+	imfMediaBufferSetCurrentLength = 6
+
+	iUnknownRelease = 2
+)
+
+// propVariant is the part of PROPVARIANT used for 64 bit integers
+type propVariant struct {
+	vt       uint16
+	reserved [3]uint16
+	val      int64
+	pad      uintptr
+}
+
+// comCall calls the method at the vtable index of a native COM object
+func comCall(obj unsafe.Pointer, index int, args ...uintptr) windows.Handle {
+	vtbl := *(**[64]uintptr)(obj)
+	ret, _, _ := syscall.SyscallN(vtbl[index], append([]uintptr{uintptr(obj)}, args...)...)
+	return windows.Handle(ret)
+}
+
+func comRelease(obj unsafe.Pointer) {
+	if obj != nil {
+		comCall(obj, iUnknownRelease)
+	}
+}
+
+func hresultError(hr windows.Handle) error {
+	if hr == windows.S_OK {
+		return nil
+	}
+	return syscall.Errno(hr)
+}
+
+// mfCreateFloatMediaType creates the IMFMediaType of 16 kHz float PCM
+func mfCreateFloatMediaType(channels uint32) (unsafe.Pointer, error) {
+	var mt unsafe.Pointer
+	ret, _, _ := procMFCreateMediaType.Call(uintptr(unsafe.Pointer(&mt)))
+	if err := hresultError(windows.Handle(ret)); err != nil {
+		return nil, err
+	}
+
+	blockAlign := channels * 4
+	guids := []struct{ key, value *windows.GUID }{
+		{&mfMTMajorType, &mfMediaTypeAudio},
+		{&mfMTSubtype, &mfAudioFormatFloat},
+	}
+	for _, g := range guids {
+		if err := hresultError(comCall(mt, imfAttributesSetGUID, uintptr(unsafe.Pointer(g.key)), uintptr(unsafe.Pointer(g.value)))); err != nil {
+			comRelease(mt)
+			return nil, err
+		}
+	}
+
+	values := []struct {
+		key   *windows.GUID
+		value uint32
+	}{
+		{&mfMTAudioNumChannels, channels},
+		{&mfMTAudioSamplesPerSecond, SampleRate},
+		{&mfMTAudioBitsPerSample, 32},
+		{&mfMTAudioBlockAlignment, blockAlign},
+		{&mfMTAudioAvgBytesPerSec, blockAlign * SampleRate},
+		{&mfMTAllSamplesIndependent, 1},
+	}
+	for _, v := range values {
+		if err := hresultError(comCall(mt, imfAttributesSetUINT32, uintptr(unsafe.Pointer(v.key)), uintptr(v.value))); err != nil {
+			comRelease(mt)
+			return nil, err
+		}
+	}
+
+	return mt, nil
+}
+
+// mfFloatChannels returns the channel count of a 16 kHz float media type, 0 for any other type
+func mfFloatChannels(mt unsafe.Pointer) uint32 {
+	var subtype windows.GUID
+	if comCall(mt, imfAttributesGetGUID, uintptr(unsafe.Pointer(&mfMTSubtype)), uintptr(unsafe.Pointer(&subtype))) != windows.S_OK || subtype != mfAudioFormatFloat {
+		return 0
+	}
+
+	var rate, channels uint32
+	if comCall(mt, imfAttributesGetUINT32, uintptr(unsafe.Pointer(&mfMTAudioSamplesPerSecond)), uintptr(unsafe.Pointer(&rate))) != windows.S_OK || rate != SampleRate {
+		return 0
+	}
+	if comCall(mt, imfAttributesGetUINT32, uintptr(unsafe.Pointer(&mfMTAudioNumChannels)), uintptr(unsafe.Pointer(&channels))) != windows.S_OK {
+		return 0
+	}
+	return channels
+}
+
+// mfCreateSample copies the samples into a new IMFSample with the given time and duration in 100ns ticks
+func mfCreateSample(pcm []float32, time, duration int64) (unsafe.Pointer, error) {
+	size := uint32(len(pcm) * 4)
+
+	var buffer unsafe.Pointer
+	ret, _, _ := procMFCreateMemBuffer.Call(uintptr(size), uintptr(unsafe.Pointer(&buffer)))
+	if err := hresultError(windows.Handle(ret)); err != nil {
+		return nil, err
+	}
+	defer comRelease(buffer)
+
+	var data *float32
+	if err := hresultError(comCall(buffer, imfMediaBufferLock, uintptr(unsafe.Pointer(&data)), 0, 0)); err != nil {
+		return nil, err
+	}
+	copy(unsafe.Slice(data, len(pcm)), pcm)
+	comCall(buffer, imfMediaBufferUnlock)
+
+	if err := hresultError(comCall(buffer, imfMediaBufferSetCurrentLength, uintptr(size))); err != nil {
+		return nil, err
+	}
+
+	var sample unsafe.Pointer
+	ret, _, _ = procMFCreateSample.Call(uintptr(unsafe.Pointer(&sample)))
+	if err := hresultError(windows.Handle(ret)); err != nil {
+		return nil, err
+	}
+
+	hr := comCall(sample, imfSampleAddBuffer, uintptr(buffer))
+	if hr == windows.S_OK {
+		hr = comCall(sample, imfSampleSetSampleTime, uintptr(time))
+	}
+	if hr == windows.S_OK {
+		hr = comCall(sample, imfSampleSetSampleDuration, uintptr(duration))
+	}
+	if err := hresultError(hr); err != nil {
+		comRelease(sample)
+		return nil, err
+	}
+	return sample, nil
+}
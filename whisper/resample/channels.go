@@ -0,0 +1,63 @@
+package resample
+
+import "fmt"
+
+// Downmix appends the average of all channels of every interleaved frame to dst.
+// This is what iAudioBuffer.getPcmMono returns for multichannel audio.
+func Downmix(dst, in []float32, channels int) ([]float32, error) {
+	if err := checkFrames(in, channels); err != nil {
+		return dst, err
+	}
+	if channels == 1 {
+		return append(dst, in...), nil
+	}
+
+	scale := 1 / float32(channels)
+	for i := 0; i < len(in); i += channels {
+		var sum float32
+		for _, s := range in[i : i+channels] {
+			sum += s
+		}
+		dst = append(dst, sum*scale)
+	}
+	return dst, nil
+}
+
+// Stereo appends interleaved stereo to dst: the first two channels of every frame, or the only channel twice.
+// This is what iAudioBuffer.getPcmStereo returns.
+func Stereo(dst, in []float32, channels int) ([]float32, error) {
+	if err := checkFrames(in, channels); err != nil {
+		return dst, err
+	}
+	if channels == 2 {
+		return append(dst, in...), nil
+	}
+	if channels == 1 {
+		return Upmix(dst, in, 2), nil
+	}
+
+	for i := 0; i < len(in); i += channels {
+		dst = append(dst, in[i], in[i+1])
+	}
+	return dst, nil
+}
+
+// Upmix appends every mono sample to dst repeated for the given number of channels
+func Upmix(dst, mono []float32, channels int) []float32 {
+	for _, s := range mono {
+		for c := 0; c < channels; c++ {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+func checkFrames(in []float32, channels int) error {
+	if channels <= 0 {
+		return fmt.Errorf("resample: invalid channel count %d", channels)
+	}
+	if len(in)%channels != 0 {
+		return fmt.Errorf("resample: %d samples is not a whole number of %d channel frames", len(in), channels)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+Package resample converts streaming float PCM between sample rates and channel layouts.
+
+The resampler is a polyphase windowed-sinc filter with a Kaiser window.
+Output only depends on the input samples and the settings, never on how the input was split into blocks.
+*/
+package resample
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+type Quality int
+
+const (
+	// Short filter, transition band starts at 80% of the output Nyquist frequency
+	Fast Quality = iota
+	// Good enough for speech recognition
+	Medium
+	// Long filter with a narrow transition band and more than 90 dB stop band attenuation
+	High
+)
+
+type qualitySettings struct {
+	// Zero crossings of the sinc on both sides together, when not downsampling
+	taps int
+	// Cutoff relative to the lower Nyquist frequency of both rates
+	rolloff float64
+	// Kaiser window shape
+	beta float64
+}
+
+var qualities = map[Quality]qualitySettings{
+	Fast:   {taps: 16, rolloff: 0.80, beta: 6},
+	Medium: {taps: 32, rolloff: 0.90, beta: 8},
+	High:   {taps: 64, rolloff: 0.95, beta: 10},
+}
+
+func (this Quality) String() string {
+	switch this {
+	case Fast:
+		return "fast"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	}
+	return fmt.Sprintf("Quality(%d)", int(this))
+}
+
+// Polyphase tables for ratios with more phases are too large; e.g. 44100 -> 16000 needs 160 phases
+const maxPhases = 2048
+
+// Resampler converts interleaved frames from one sample rate to another, keeping state between blocks.
+// It is not safe for concurrent use.
+type Resampler struct {
+	inRate, outRate int
+	channels        int
+
+	// Output rate / input rate reduced to up / down
+	up, down int
+
+	// Taps per phase, and the filter of every phase
+	taps   int
+	phases [][]float32
+
+	// Input frames which may still contribute to output, interleaved; buffer[0] is input frame bufferStart
+	buffer      []float32
+	bufferStart int64
+
+	// Position of the next output frame, in input frames: index + phase / up
+	index int64
+	phase int
+
+	framesIn  int64
+	framesOut int64
+	flushed   bool
+}
+
+// New creates a resampler for interleaved audio with the given number of channels
+func New(inRate, outRate, channels int, quality Quality) (*Resampler, error) {
+	if inRate <= 0 || outRate <= 0 {
+		return nil, fmt.Errorf("resample: invalid sample rates %d -> %d", inRate, outRate)
+	}
+	if channels <= 0 {
+		return nil, fmt.Errorf("resample: invalid channel count %d", channels)
+	}
+	settings, ok := qualities[quality]
+	if !ok {
+		return nil, fmt.Errorf("resample: invalid quality %d", int(quality))
+	}
+
+	g := gcd(inRate, outRate)
+	this := &Resampler{
+		inRate:   inRate,
+		outRate:  outRate,
+		channels: channels,
+		up:       outRate / g,
+		down:     inRate / g,
+	}
+	if this.up > maxPhases {
+		return nil, fmt.Errorf("resample: ratio %d/%d needs too many filter phases", this.up, this.down)
+	}
+
+	this.makeFilter(settings)
+	this.Reset()
+	return this, nil
+}
+
+// makeFilter computes the windowed sinc for every phase, normalised to unity gain at DC
+func (this *Resampler) makeFilter(settings qualitySettings) {
+	// When downsampling, the cutoff moves down to the output Nyquist frequency and the filter widens to match
+	ratio := math.Min(1, float64(this.up)/float64(this.down))
+	taps := int(math.Ceil(float64(settings.taps) / ratio))
+	taps += taps & 1
+	this.taps = taps
+
+	cutoff := 0.5 * settings.rolloff * ratio
+	half := float64(taps) / 2
+	i0Beta := besselI0(settings.beta)
+
+	this.phases = make([][]float32, this.up)
+	for p := range this.phases {
+		row := make([]float32, taps)
+		offset := float64(p) / float64(this.up)
+
+		var sum float64
+		values := make([]float64, taps)
+		for j := range values {
+			// Distance in input frames between the output position and input frame (index - taps/2 + 1 + j)
+			t := offset + half - 1 - float64(j)
+			x := t / half
+			if x <= -1 || x >= 1 {
+				continue
+			}
+			window := besselI0(settings.beta*math.Sqrt(1-x*x)) / i0Beta
+			values[j] = 2 * cutoff * sinc(2*cutoff*t) * window
+			sum += values[j]
+		}
+		for j := range values {
+			row[j] = float32(values[j] / sum)
+		}
+		this.phases[p] = row
+	}
+}
+
+// Reset forgets all input, the next Process call starts a new stream
+func (this *Resampler) Reset() {
+	history := this.taps/2 - 1
+	this.buffer = make([]float32, history*this.channels, (history+4096)*this.channels)
+	this.bufferStart = -int64(history)
+	this.index = 0
+	this.phase = 0
+	this.framesIn = 0
+	this.framesOut = 0
+	this.flushed = false
+}
+
+func (this *Resampler) InRate() int   { return this.inRate }
+func (this *Resampler) OutRate() int  { return this.outRate }
+func (this *Resampler) Channels() int { return this.channels }
+
+// Latency is the number of input frames the resampler holds back until more input or Flush arrives
+func (this *Resampler) Latency() int {
+	return this.taps / 2
+}
+
+// OutputLength returns the number of frames the resampler produces for inputFrames, including Flush
+func (this *Resampler) OutputLength(inputFrames int64) int64 {
+	return (inputFrames*int64(this.up) + int64(this.down) - 1) / int64(this.down)
+}
+
+var errFlushed = errors.New("resample: Process after Flush, call Reset first")
+
+// Process resamples a block of interleaved frames, and appends the frames which are complete to dst.
+// The length of the input must be a multiple of the channel count.
+func (this *Resampler) Process(dst, in []float32) ([]float32, error) {
+	if this.flushed {
+		return dst, errFlushed
+	}
+	if len(in)%this.channels != 0 {
+		return dst, fmt.Errorf("resample: %d samples is not a whole number of %d channel frames", len(in), this.channels)
+	}
+
+	if this.up == this.down {
+		this.framesIn += int64(len(in) / this.channels)
+		this.framesOut = this.framesIn
+		return append(dst, in...), nil
+	}
+
+	this.buffer = append(this.buffer, in...)
+	this.framesIn += int64(len(in) / this.channels)
+	return this.drain(dst, this.framesIn), nil
+}
+
+// Flush returns the remaining frames at the end of the stream.
+// The total output is OutputLength of the total input, with the first output frame aligned to the first input frame.
+func (this *Resampler) Flush(dst []float32) []float32 {
+	if this.flushed {
+		return dst
+	}
+	this.flushed = true
+	if this.up == this.down {
+		return dst
+	}
+
+	// Zeros after the end of the input
+	this.buffer = append(this.buffer, make([]float32, (this.taps/2)*this.channels)...)
+	dst = this.drain(dst, this.framesIn+int64(this.taps/2))
+
+	return dst
+}
+
+// drain appends the output frames whose filter is covered by the input frames before available
+func (this *Resampler) drain(dst []float32, available int64) []float32 {
+	ch := this.channels
+	half := int64(this.taps / 2)
+	total := this.OutputLength(this.framesIn)
+
+	// The last tap of the next output frame reads input frame index + half
+	for this.index+half < available {
+		if this.flushed && this.framesOut >= total {
+			break
+		}
+
+		row := this.phases[this.phase]
+		first := int(this.index - half + 1 - this.bufferStart)
+		window := this.buffer[first*ch : (first+this.taps)*ch]
+
+		for c := 0; c < ch; c++ {
+			var acc float32
+			for j, h := range row {
+				acc += h * window[j*ch+c]
+			}
+			dst = append(dst, acc)
+		}
+		this.framesOut++
+
+		this.phase += this.down
+		this.index += int64(this.phase / this.up)
+		this.phase %= this.up
+	}
+
+	// Drop the frames no later output needs
+	if drop := int(this.index - half + 1 - this.bufferStart); drop > 0 {
+		if drop > len(this.buffer)/ch {
+			drop = len(this.buffer) / ch
+		}
+		n := copy(this.buffer, this.buffer[drop*ch:])
+		this.buffer = this.buffer[:n]
+		this.bufferStart += int64(drop)
+	}
+	return dst
+}
+
+// ************************************************************
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// besselI0 is the modified Bessel function of the first kind, order 0, from its power series
+func besselI0(x float64) float64 {
+	sum := 1.0
+	term := 1.0
+	y := x * x / 4
+	for k := 1; k < 100; k++ {
+		term *= y / float64(k*k)
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
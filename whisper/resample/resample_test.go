@@ -0,0 +1,131 @@
+package resample
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(rate int, freq, amplitude float64, frames int) []float32 {
+	pcm := make([]float32, frames)
+	for i := range pcm {
+		pcm[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return pcm
+}
+
+func resampleAll(t testing.TB, inRate, outRate int, quality Quality, in []float32, blocks []int) []float32 {
+	r, err := New(inRate, outRate, 1, quality)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []float32
+	for pos, i := 0, 0; pos < len(in); i++ {
+		n := len(in) - pos
+		if len(blocks) > 0 && blocks[i%len(blocks)] < n {
+			n = blocks[i%len(blocks)]
+		}
+		if out, err = r.Process(out, in[pos:pos+n]); err != nil {
+			t.Fatal(err)
+		}
+		pos += n
+	}
+	return r.Flush(out)
+}
+
+// The output frames away from both ends, where the filter sees only input
+func steady(pcm []float32, rate int) []float32 {
+	return pcm[rate/10 : len(pcm)-rate/10]
+}
+
+func maxError(got []float32, want func(i int) float64) float64 {
+	worst := 0.0
+	for i, v := range got {
+		worst = math.Max(worst, math.Abs(float64(v)-want(i)))
+	}
+	return worst
+}
+
+func peak(pcm []float32) float64 {
+	worst := 0.0
+	for _, v := range pcm {
+		worst = math.Max(worst, math.Abs(float64(v)))
+	}
+	return worst
+}
+
+// A tone in the pass band comes out with the same amplitude and phase, as computed for the output rate
+func TestSineAccuracy(t *testing.T) {
+	tolerance := map[Quality]float64{Fast: 2e-3, Medium: 1e-5, High: 2e-6}
+
+	for _, inRate := range []int{44100, 48000} {
+		for _, quality := range []Quality{Fast, Medium, High} {
+			in := sine(inRate, 1000, 0.5, inRate*2)
+			out := resampleAll(t, inRate, 16000, quality, in, nil)
+
+			r, _ := New(inRate, 16000, 1, quality)
+			if want := r.OutputLength(int64(len(in))); int64(len(out)) != want {
+				t.Fatalf("%d %s: %d frames, expected %d", inRate, quality, len(out), want)
+			}
+
+			offset := 16000 / 10
+			got := maxError(steady(out, 16000), func(i int) float64 {
+				return 0.5 * math.Sin(2*math.Pi*1000*float64(i+offset)/16000)
+			})
+			if got > tolerance[quality] {
+				t.Errorf("%d %s: error %g, expected at most %g", inRate, quality, got, tolerance[quality])
+			}
+		}
+	}
+}
+
+// A tone above the output Nyquist frequency is filtered out rather than aliased
+func TestAliasingRejected(t *testing.T) {
+	limit := map[Quality]float64{Fast: 2e-3, Medium: 2e-4, High: 2e-5}
+
+	for _, inRate := range []int{44100, 48000} {
+		for _, quality := range []Quality{Fast, Medium, High} {
+			in := sine(inRate, 10000, 0.5, inRate)
+			out := resampleAll(t, inRate, 16000, quality, in, nil)
+
+			if got := peak(steady(out, 16000)); got > limit[quality] {
+				t.Errorf("%d %s: aliased peak %g, expected at most %g", inRate, quality, got, limit[quality])
+			}
+		}
+	}
+}
+
+// Splitting the input into blocks gives the same output as one call
+func TestBlocksMatchOneShot(t *testing.T) {
+	in := sine(44100, 440, 0.8, 44100)
+	oneShot := resampleAll(t, 44100, 16000, Medium, in, nil)
+
+	for _, blocks := range [][]int{{1}, {7, 100, 1, 4096}, {1000}} {
+		got := resampleAll(t, 44100, 16000, Medium, in, blocks)
+		if len(got) != len(oneShot) {
+			t.Fatalf("blocks %v: %d frames, expected %d", blocks, len(got), len(oneShot))
+		}
+		for i := range got {
+			if got[i] != oneShot[i] {
+				t.Fatalf("blocks %v: frame %d is %g, expected %g", blocks, i, got[i], oneShot[i])
+			}
+		}
+	}
+}
+
+func BenchmarkResample(b *testing.B) {
+	in := sine(44100, 1000, 0.5, 4096)
+	r, err := New(44100, 16000, 1, Medium)
+	if err != nil {
+		b.Fatal(err)
+	}
+	out := make([]float32, 0, 4096)
+
+	b.SetBytes(int64(len(in) * 4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if out, err = r.Process(out[:0], in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
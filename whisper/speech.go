@@ -0,0 +1,70 @@
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaybinks/goConstmeWhisper/whisper/vad"
+)
+
+// DetectSpeech runs the voice activity detector over the mono PCM of the buffer
+func DetectSpeech(buffer *iAudioBuffer, config vad.Config) ([]vad.Region, error) {
+	if buffer == nil {
+		return nil, errors.New("DetectSpeech: buffer is nil")
+	}
+
+	pcm, err := buffer.PcmMono()
+	if err != nil {
+		return nil, err
+	}
+
+	config.SampleRate = SampleRate
+	return vad.Detect(pcm, config)
+}
+
+// RunSpeechRegions runs RunFull once per region, limiting it with the offset and duration of the params,
+// and returns the segments of all regions in one transcript.
+// Segment times are relative to the start of the buffer, like those of RunFull.
+// The offset and duration of the params are restored afterwards.
+func (context *IContext) RunSpeechRegions(params *FullParams, buffer *iAudioBuffer, regions []vad.Region, flags eResultFlags) (*Transcript, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+
+	offset, duration := params.Offset(), params.Duration()
+	defer func() {
+		params.SetOffset(offset)
+		params.SetDuration(duration)
+	}()
+
+	transcript := &Transcript{}
+	for i, region := range regions {
+		// sFullParams has millisecond resolution, round outwards so the whole region is transcribed
+		begin := region.Begin.Truncate(time.Millisecond)
+		end := (region.End + time.Millisecond - 1).Truncate(time.Millisecond)
+		if end <= begin {
+			// 0 would mean until the end of the audio
+			end = begin + time.Millisecond
+		}
+
+		if err := params.SetOffset(begin); err != nil {
+			return nil, fmt.Errorf("speech region %d: %w", i, err)
+		}
+		if err := params.SetDuration(end - begin); err != nil {
+			return nil, fmt.Errorf("speech region %d: %w", i, err)
+		}
+
+		if err := context.RunFull(params, buffer); err != nil {
+			return nil, fmt.Errorf("speech region %d: %w", i, err)
+		}
+
+		part, err := context.Transcript(flags)
+		if err != nil {
+			return nil, fmt.Errorf("speech region %d: %w", i, err)
+		}
+		transcript.Segments = append(transcript.Segments, part.Segments...)
+	}
+
+	return transcript, nil
+}
@@ -0,0 +1,220 @@
+package whisper
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fewer matching words than this at a window boundary are treated as a coincidence, e.g. "the"
+const minStitchWords = 2
+
+// Stitch appends the segments of next, which overlaps the end of this transcript.
+// Both transcripts must have times relative to the same audio, see Shift,
+// and [overlapBegin, overlapEnd) is the audio both of them were transcribed from.
+//
+// Every segment within the overlap is taken from the transcript whose window covers its center best:
+// this one before the middle of the overlap, next after it. The text repeated across that cut is then removed,
+// either a whole segment contained in its neighbour, or words at the start of next repeating the end of this one.
+func (this *Transcript) Stitch(next *Transcript, overlapBegin, overlapEnd time.Duration) {
+	if next == nil || len(next.Segments) == 0 {
+		return
+	}
+	if overlapEnd <= overlapBegin || len(this.Segments) == 0 {
+		this.Segments = append(this.Segments, next.Segments...)
+		return
+	}
+
+	cut := overlapBegin + (overlapEnd-overlapBegin)/2
+
+	keep := len(this.Segments)
+	for keep > 0 && segmentCenter(&this.Segments[keep-1]) >= cut {
+		keep--
+	}
+	this.Segments = this.Segments[:keep]
+
+	first := 0
+	for first < len(next.Segments) && segmentCenter(&next.Segments[first]) < cut {
+		first++
+	}
+	added := next.Segments[first:]
+
+	if len(this.Segments) > 0 && len(added) > 0 {
+		last := &this.Segments[len(this.Segments)-1]
+		lastWords := normalizedWords(last.Text)
+		firstWords := normalizedWords(added[0].Text)
+
+		switch {
+		case len(firstWords) == 0:
+		case containsWords(lastWords, firstWords):
+			// The earlier window already has the whole segment
+			added = added[1:]
+		case containsWords(firstWords, lastWords):
+			// The earlier window cut the segment short
+			this.Segments = this.Segments[:len(this.Segments)-1]
+		default:
+			if n := overlapWords(lastWords, firstWords); n >= minStitchWords {
+				seg := trimLeadingWords(added[0], n)
+				added = append([]Segment{seg}, added[1:]...)
+			}
+		}
+	}
+
+	this.Segments = append(this.Segments, added...)
+}
+
+func segmentCenter(seg *Segment) time.Duration {
+	return seg.Begin + (seg.End-seg.Begin)/2
+}
+
+// normalizedWords splits the text into lowercase words without punctuation, every CJK character is a word
+func normalizedWords(text string) []string {
+	var words []string
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case isWordCharacter(r):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			word.WriteRune(unicode.ToLower(r))
+		case r == '\'' || r == '’':
+			// Contractions stay one word
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// containsWords is true when needle is a non empty contiguous run of haystack
+func containsWords(haystack, needle []string) bool {
+	if len(needle) == 0 || len(needle) > len(haystack) {
+		return false
+	}
+outer:
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// overlapWords returns the length of the longest suffix of a which is also a prefix of b
+func overlapWords(a, b []string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+outer:
+	for ; n > 0; n-- {
+		suffix := a[len(a)-n:]
+		for i := range suffix {
+			if suffix[i] != b[i] {
+				continue outer
+			}
+		}
+		return n
+	}
+	return 0
+}
+
+// trimLeadingWords returns a copy of the segment without its first count normalized words.
+// Tokens of the removed words are dropped too, and the segment begins at the first remaining token.
+func trimLeadingWords(seg Segment, count int) Segment {
+	seg.Text = skipWords(seg.Text, count)
+
+	if len(seg.Tokens) == 0 {
+		return seg
+	}
+
+	// Drop the tokens whose text is entirely within the removed words
+	var text strings.Builder
+	removed := 0
+	for removed < len(seg.Tokens) {
+		tok := &seg.Tokens[removed]
+		if tok.IsSpecial() {
+			removed++
+			continue
+		}
+		if len(normalizedWords(text.String()+tok.Text)) > count {
+			break
+		}
+		text.WriteString(tok.Text)
+		removed++
+	}
+
+	// Only the leading special tokens, e.g. [_BEG_], are kept
+	var tokens []Token
+	for i := 0; i < removed; i++ {
+		if seg.Tokens[i].IsSpecial() && len(tokens) == i {
+			tokens = append(tokens, seg.Tokens[i])
+		}
+	}
+	tokens = append(tokens, seg.Tokens[removed:]...)
+	seg.Tokens = tokens
+
+	for i := range tokens {
+		if !tokens[i].IsSpecial() {
+			seg.Begin = tokens[i].Begin
+			break
+		}
+	}
+	return seg
+}
+
+// skipWords removes the first count normalized words from the text, and the punctuation after them
+func skipWords(text string, count int) string {
+	offset := 0
+	for offset < len(text) && count > 0 {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if isWordCharacter(r) {
+			count--
+			offset += size
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			// Skip the rest of the word
+			for offset < len(text) {
+				r, size = utf8.DecodeRuneInString(text[offset:])
+				if !(unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '\'' || r == '’') || isWordCharacter(r) {
+					break
+				}
+				offset += size
+			}
+			count--
+			continue
+		}
+		offset += size
+	}
+
+	// Punctuation which closed the removed words
+	for offset < len(text) {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if !isClosingPunct(r) {
+			break
+		}
+		offset += size
+	}
+
+	rest := strings.TrimLeftFunc(text[offset:], unicode.IsSpace)
+	if rest == "" {
+		return ""
+	}
+	// Segment text starts with a space, like the text the model produces
+	return " " + rest
+}
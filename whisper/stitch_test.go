@@ -0,0 +1,57 @@
+package whisper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStitchWindowEdges(t *testing.T) {
+	seconds := func(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+	seg := func(text string, begin, end float64) Segment {
+		return Segment{Text: text, Begin: seconds(begin), End: seconds(end)}
+	}
+
+	for _, test := range []struct {
+		name string
+		prev []Segment
+		next []Segment
+		want []string
+	}{
+		{
+			"same segment on both sides of the cut",
+			[]Segment{seg(" one two three", 10, 14.9), seg(" four five six", 15, 17.9)},
+			[]Segment{seg(" four five six", 15.2, 18.1), seg(" seven eight", 18.2, 22)},
+			[]string{" one two three", " four five six", " seven eight"},
+		},
+		{
+			"earlier window cut the segment short",
+			[]Segment{seg(" one two", 10, 14), seg(" the quick brown", 14.5, 15.9)},
+			[]Segment{seg(" the quick brown fox jumps", 16.5, 19.5)},
+			[]string{" one two", " the quick brown fox jumps"},
+		},
+		{
+			"next window repeats the last words",
+			[]Segment{seg(" hello there general kenobi", 13, 17.9)},
+			[]Segment{seg(" general kenobi, you are bold", 18.1, 21)},
+			[]string{" hello there general kenobi", " you are bold"},
+		},
+		{
+			"a single common word is a coincidence",
+			[]Segment{seg(" pass me the", 13, 17.9)},
+			[]Segment{seg(" the end", 18.1, 21)},
+			[]string{" pass me the", " the end"},
+		},
+	} {
+		result := &Transcript{Segments: test.prev}
+		result.Stitch(&Transcript{Segments: test.next}, 16*time.Second, 20*time.Second)
+
+		var got []string
+		for _, s := range result.Segments {
+			got = append(got, s.Text)
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", test.want) {
+			t.Errorf("%s: %q, expected %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package whisper
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Go callbacks only receive integer arguments. newFloatThunk returns native code which moves a double passed
+// first, in XMM0, into RCX and jumps to the callback, so it receives the bits of the double as its first uintptr.
+// The other arguments are left in place. x64 only, like whisper.dll.
+func newFloatThunk(callback uintptr) (uintptr, error) {
+	if runtime.GOARCH != "amd64" {
+		return 0, fmt.Errorf("float thunks are not implemented on %s", runtime.GOARCH)
+	}
+
+	code := []byte{
+		0x66, 0x48, 0x0F, 0x7E, 0xC1, // movq rcx, xmm0
+		0x48, 0xB8, 0, 0, 0, 0, 0, 0, 0, 0, // mov rax, callback
+		0xFF, 0xE0, // jmp rax
+	}
+	for i := 0; i < 8; i++ {
+		code[7+i] = byte(callback >> (8 * i))
+	}
+
+	addr, err := windows.VirtualAlloc(0, uintptr(len(code)), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		return 0, fmt.Errorf("float thunk: %w", err)
+	}
+
+	// VirtualAlloc memory is outside of the Go heap
+	copy(unsafe.Slice((*byte)(syscallPointer(addr)), len(code)), code)
+
+	var old uint32
+	if err := windows.VirtualProtect(addr, uintptr(len(code)), windows.PAGE_EXECUTE_READ, &old); err != nil {
+		windows.VirtualFree(addr, 0, windows.MEM_RELEASE)
+		return 0, fmt.Errorf("float thunk: %w", err)
+	}
+	return addr, nil
+}
@@ -0,0 +1,59 @@
+package vad
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft is an in-place radix-2 transform of a fixed power of 2 size
+type fft struct {
+	size    int
+	twiddle []complex128
+	reverse []int
+}
+
+func newFFT(size int) *fft {
+	bits := 0
+	for 1<<bits < size {
+		bits++
+	}
+
+	this := &fft{
+		size:    size,
+		twiddle: make([]complex128, size/2),
+		reverse: make([]int, size),
+	}
+	for i := range this.twiddle {
+		this.twiddle[i] = cmplx.Exp(complex(0, -2*math.Pi*float64(i)/float64(size)))
+	}
+	for i := range this.reverse {
+		r := 0
+		for b := 0; b < bits; b++ {
+			if i&(1<<b) != 0 {
+				r |= 1 << (bits - 1 - b)
+			}
+		}
+		this.reverse[i] = r
+	}
+	return this
+}
+
+func (this *fft) transform(x []complex128) {
+	for i, r := range this.reverse {
+		if i < r {
+			x[i], x[r] = x[r], x[i]
+		}
+	}
+
+	for length := 2; length <= this.size; length <<= 1 {
+		half := length / 2
+		step := this.size / length
+		for start := 0; start < this.size; start += length {
+			for k := 0; k < half; k++ {
+				t := this.twiddle[k*step] * x[start+k+half]
+				x[start+k+half] = x[start+k] - t
+				x[start+k] += t
+			}
+		}
+	}
+}
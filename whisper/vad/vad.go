@@ -0,0 +1,332 @@
+/*
+Package vad finds speech in 16 kHz float PCM, so silence doesn't have to be transcribed.
+
+A frame is speech when it is loud enough both in absolute terms and relative to the noise floor,
+and its spectrum looks like a voice: most of the energy within 80 - 4000 Hz, and not flat like noise.
+Speech frames are joined into regions, bridging pauses shorter than the hangover.
+*/
+package vad
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Config of the detector; start from DefaultConfig
+type Config struct {
+	// Sample rate of the PCM
+	SampleRate int
+	// Length of the analysis frames
+	Frame time.Duration
+
+	// Minimum ratio in dB of the frame energy to the noise floor
+	Threshold float64
+	// Frames quieter than this, in dB relative to full scale, are never speech
+	MinEnergy float64
+	// Noise floor in dBFS assumed until NoiseWindow of audio was seen
+	InitialNoise float64
+	// The noise floor is the quietest frame within this much of the recent audio
+	NoiseWindow time.Duration
+
+	// Maximum spectral flatness within the speech band, 1 for white noise, close to 0 for voiced speech
+	MaxFlatness float64
+	// Minimum share of the frame energy within the speech band
+	MinBandRatio float64
+
+	// Pauses shorter than this don't end a region
+	Hangover time.Duration
+	// Regions with less speech, not counting the pauses, are dropped
+	MinSpeech time.Duration
+	// Added before and after every region, without overlapping the previous one
+	Padding time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		SampleRate:   16000,
+		Frame:        20 * time.Millisecond,
+		Threshold:    10,
+		MinEnergy:    -50,
+		InitialNoise: -65,
+		NoiseWindow:  3 * time.Second,
+		MaxFlatness:  0.3,
+		MinBandRatio: 0.6,
+		Hangover:     400 * time.Millisecond,
+		MinSpeech:    200 * time.Millisecond,
+		Padding:      100 * time.Millisecond,
+	}
+}
+
+func (this *Config) validate() error {
+	switch {
+	case this.SampleRate <= 0:
+		return fmt.Errorf("vad: invalid sample rate %d", this.SampleRate)
+	case this.Frame < 5*time.Millisecond || this.Frame > 100*time.Millisecond:
+		return fmt.Errorf("vad: frame length %v is outside of 5ms - 100ms", this.Frame)
+	case this.NoiseWindow < this.Frame:
+		return fmt.Errorf("vad: noise window %v is shorter than a frame", this.NoiseWindow)
+	case this.MaxFlatness <= 0 || this.MaxFlatness > 1:
+		return fmt.Errorf("vad: MaxFlatness %v is outside of (0, 1]", this.MaxFlatness)
+	case this.MinBandRatio < 0 || this.MinBandRatio > 1:
+		return fmt.Errorf("vad: MinBandRatio %v is outside of [0, 1]", this.MinBandRatio)
+	case this.Hangover < 0 || this.MinSpeech < 0 || this.Padding < 0:
+		return fmt.Errorf("vad: negative duration")
+	}
+	return nil
+}
+
+// Region of speech in the audio
+type Region struct {
+	Begin time.Duration
+	End   time.Duration
+}
+
+func (this Region) Duration() time.Duration {
+	return this.End - this.Begin
+}
+
+func (this Region) String() string {
+	return fmt.Sprintf("%v - %v", this.Begin, this.End)
+}
+
+// Detect returns the speech regions of the whole buffer
+func Detect(pcm []float32, config Config) ([]Region, error) {
+	detector, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	regions := detector.Write(pcm)
+	return append(regions, detector.Flush()...), nil
+}
+
+// Detector finds speech regions incrementally.
+// Feeding the audio in blocks of any size gives the same regions as Detect.
+type Detector struct {
+	config Config
+
+	frameLength int
+	hangover    int64
+	minSpeech   int64
+	padding     int64
+
+	// Samples of the incomplete frame
+	partial []float32
+	// Samples analysed or buffered so far
+	position int64
+
+	// Frame energies in dBFS within the noise window, a ring buffer
+	energies []float64
+	next     int
+
+	fft      *fft
+	spectrum []complex128
+	window   []float64
+	// FFT bins of the speech band
+	bandLow, bandHigh int
+
+	// The region being built, in samples
+	inRegion    bool
+	regionBegin int64
+	speechEnd   int64
+	// Samples of the speech frames in the region
+	speech int64
+	// End of the last region returned, regions never overlap
+	lastEnd int64
+}
+
+func New(config Config) (*Detector, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	this := &Detector{
+		config:      config,
+		frameLength: toSamples(config.Frame, config.SampleRate),
+		hangover:    int64(toSamples(config.Hangover, config.SampleRate)),
+		minSpeech:   int64(toSamples(config.MinSpeech, config.SampleRate)),
+		padding:     int64(toSamples(config.Padding, config.SampleRate)),
+	}
+
+	size := 1
+	for size < this.frameLength {
+		size <<= 1
+	}
+	this.fft = newFFT(size)
+	this.spectrum = make([]complex128, size)
+
+	// Hann window
+	this.window = make([]float64, this.frameLength)
+	for i := range this.window {
+		this.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(this.frameLength))
+	}
+
+	hz := float64(config.SampleRate) / float64(size)
+	this.bandLow = int(math.Ceil(80 / hz))
+	this.bandHigh = int(math.Min(4000/hz, float64(size/2)))
+
+	this.energies = make([]float64, int(config.NoiseWindow/config.Frame))
+	this.Reset()
+	return this, nil
+}
+
+// Reset forgets all audio, the next Write starts at time 0
+func (this *Detector) Reset() {
+	this.partial = this.partial[:0]
+	this.position = 0
+	for i := range this.energies {
+		this.energies[i] = this.config.InitialNoise
+	}
+	this.next = 0
+	this.inRegion = false
+	this.lastEnd = 0
+}
+
+// Write analyses more audio, and returns the regions which ended within it
+func (this *Detector) Write(pcm []float32) []Region {
+	var regions []Region
+
+	// Complete the partial frame first
+	if len(this.partial) > 0 {
+		n := this.frameLength - len(this.partial)
+		if n > len(pcm) {
+			n = len(pcm)
+		}
+		this.partial = append(this.partial, pcm[:n]...)
+		pcm = pcm[n:]
+		this.position += int64(n)
+
+		if len(this.partial) < this.frameLength {
+			return nil
+		}
+		regions = this.addFrame(this.partial, this.position-int64(this.frameLength), regions)
+		this.partial = this.partial[:0]
+	}
+
+	for len(pcm) >= this.frameLength {
+		this.position += int64(this.frameLength)
+		regions = this.addFrame(pcm[:this.frameLength], this.position-int64(this.frameLength), regions)
+		pcm = pcm[this.frameLength:]
+	}
+
+	this.partial = append(this.partial, pcm...)
+	this.position += int64(len(pcm))
+	return regions
+}
+
+// Flush ends the open region, if any, at the end of the audio.
+// An incomplete trailing frame is not analysed.
+func (this *Detector) Flush() []Region {
+	if !this.inRegion {
+		return nil
+	}
+	return this.closeRegion(nil)
+}
+
+// addFrame analyses the frame which starts at sample begin
+func (this *Detector) addFrame(frame []float32, begin int64, regions []Region) []Region {
+	end := begin + int64(len(frame))
+
+	if this.isSpeech(frame) {
+		if !this.inRegion {
+			this.inRegion = true
+			this.regionBegin = begin
+			this.speech = 0
+		}
+		this.speechEnd = end
+		this.speech += int64(len(frame))
+		return regions
+	}
+
+	if this.inRegion && end-this.speechEnd >= this.hangover {
+		regions = this.closeRegion(regions)
+	}
+	return regions
+}
+
+func (this *Detector) closeRegion(regions []Region) []Region {
+	this.inRegion = false
+	if this.speech < this.minSpeech {
+		return regions
+	}
+
+	begin := this.regionBegin - this.padding
+	if begin < this.lastEnd {
+		begin = this.lastEnd
+	}
+	end := this.speechEnd + this.padding
+	if end > this.position {
+		end = this.position
+	}
+	this.lastEnd = end
+
+	return append(regions, Region{Begin: this.duration(begin), End: this.duration(end)})
+}
+
+func (this *Detector) isSpeech(frame []float32) bool {
+	var sum float64
+	for _, s := range frame {
+		sum += float64(s) * float64(s)
+	}
+	energy := 10 * math.Log10(sum/float64(len(frame))+1e-20)
+
+	noise := this.noiseFloor()
+	this.energies[this.next] = energy
+	this.next = (this.next + 1) % len(this.energies)
+
+	if energy < this.config.MinEnergy || energy < noise+this.config.Threshold {
+		return false
+	}
+
+	flatness, bandRatio := this.spectralFeatures(frame)
+	return flatness <= this.config.MaxFlatness && bandRatio >= this.config.MinBandRatio
+}
+
+// noiseFloor is the minimum frame energy within the noise window
+func (this *Detector) noiseFloor() float64 {
+	floor := this.energies[0]
+	for _, e := range this.energies[1:] {
+		floor = math.Min(floor, e)
+	}
+	return floor
+}
+
+// spectralFeatures returns the spectral flatness within the speech band, and the share of the energy within the band
+func (this *Detector) spectralFeatures(frame []float32) (float64, float64) {
+	for i := range this.spectrum {
+		if i < len(frame) {
+			this.spectrum[i] = complex(float64(frame[i])*this.window[i], 0)
+		} else {
+			this.spectrum[i] = 0
+		}
+	}
+	this.fft.transform(this.spectrum)
+
+	var total, band, logSum float64
+	// Bin 0 is DC, which is an offset rather than sound
+	for k := 1; k <= len(this.spectrum)/2; k++ {
+		re, im := real(this.spectrum[k]), imag(this.spectrum[k])
+		power := re*re + im*im
+		total += power
+		if k >= this.bandLow && k <= this.bandHigh {
+			band += power
+			logSum += math.Log(power + 1e-20)
+		}
+	}
+
+	if total == 0 || band == 0 {
+		return 1, 0
+	}
+	bins := float64(this.bandHigh - this.bandLow + 1)
+	flatness := math.Exp(logSum/bins) / (band / bins)
+	return flatness, band / total
+}
+
+func (this *Detector) duration(samples int64) time.Duration {
+	return time.Duration(samples) * time.Second / time.Duration(this.config.SampleRate)
+}
+
+func toSamples(d time.Duration, rate int) int {
+	return int(d * time.Duration(rate) / time.Second)
+}
@@ -0,0 +1,195 @@
+package vad
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+const rate = 16000
+
+func samples(d time.Duration) int {
+	return toSamples(d, rate)
+}
+
+// noise adds white noise with the given RMS level in dBFS
+func noise(pcm []float32, level float64, seed int64) {
+	random := rand.New(rand.NewSource(seed))
+	scale := math.Pow(10, level/20) * math.Sqrt(3)
+	for i := range pcm {
+		pcm[i] += float32(scale * (2*random.Float64() - 1))
+	}
+}
+
+// tone adds a sine between begin and end
+func tone(pcm []float32, freq, amplitude float64, begin, end time.Duration) {
+	for i := samples(begin); i < samples(end); i++ {
+		pcm[i] += float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/rate))
+	}
+}
+
+// voice adds a harmonic series like a voiced vowel between begin and end
+func voice(pcm []float32, begin, end time.Duration) {
+	for h := 1; h <= 20; h++ {
+		tone(pcm, 150*float64(h), 0.2/float64(h), begin, end)
+	}
+}
+
+func detect(t *testing.T, pcm []float32) []Region {
+	regions, err := Detect(pcm, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return regions
+}
+
+func expectRegions(t *testing.T, got []Region, want ...Region) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("regions %v, expected %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("regions %v, expected %v", got, want)
+		}
+	}
+}
+
+func TestSilence(t *testing.T) {
+	pcm := make([]float32, samples(3*time.Second))
+	expectRegions(t, detect(t, pcm))
+
+	// Quieter than MinEnergy
+	noise(pcm, -70, 1)
+	expectRegions(t, detect(t, pcm))
+}
+
+func TestWhiteNoise(t *testing.T) {
+	pcm := make([]float32, samples(3*time.Second))
+	noise(pcm, -70, 1)
+	noise(pcm[samples(time.Second):samples(2*time.Second)], -20, 2)
+	expectRegions(t, detect(t, pcm))
+}
+
+func TestTone(t *testing.T) {
+	// A tone within the speech band is not flat, so it passes like a voice
+	pcm := make([]float32, samples(3*time.Second))
+	noise(pcm, -70, 1)
+	tone(pcm, 1000, 0.3, time.Second, 2*time.Second)
+	expectRegions(t, detect(t, pcm), Region{900 * time.Millisecond, 2100 * time.Millisecond})
+
+	// Above the speech band it doesn't
+	pcm = make([]float32, samples(3*time.Second))
+	noise(pcm, -70, 1)
+	tone(pcm, 6000, 0.3, time.Second, 2*time.Second)
+	expectRegions(t, detect(t, pcm))
+}
+
+func TestBursts(t *testing.T) {
+	pcm := make([]float32, samples(6*time.Second))
+	noise(pcm, -70, 1)
+
+	// The 200ms pause is shorter than the hangover, the 1.3s one isn't
+	voice(pcm, 1000*time.Millisecond, 1500*time.Millisecond)
+	voice(pcm, 1700*time.Millisecond, 2200*time.Millisecond)
+	voice(pcm, 3500*time.Millisecond, 4000*time.Millisecond)
+	// Shorter than MinSpeech
+	voice(pcm, 5000*time.Millisecond, 5100*time.Millisecond)
+
+	expectRegions(t, detect(t, pcm),
+		Region{900 * time.Millisecond, 2300 * time.Millisecond},
+		Region{3400 * time.Millisecond, 4100 * time.Millisecond},
+	)
+}
+
+func TestHangoverBoundary(t *testing.T) {
+	config := DefaultConfig()
+	config.Padding = 0
+
+	for _, test := range []struct {
+		pause   time.Duration
+		regions int
+	}{
+		{config.Hangover - config.Frame, 1},
+		{config.Hangover, 2},
+	} {
+		pcm := make([]float32, samples(4*time.Second))
+		noise(pcm, -70, 1)
+		voice(pcm, time.Second, 1500*time.Millisecond)
+		voice(pcm, 1500*time.Millisecond+test.pause, 2*time.Second+test.pause)
+
+		regions, err := Detect(pcm, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(regions) != test.regions {
+			t.Errorf("pause %v: regions %v, expected %d", test.pause, regions, test.regions)
+		}
+	}
+}
+
+func TestRegionOpenAtEnd(t *testing.T) {
+	pcm := make([]float32, samples(2*time.Second))
+	noise(pcm, -70, 1)
+	voice(pcm, time.Second, 2*time.Second)
+
+	// The padding is cut at the end of the audio
+	expectRegions(t, detect(t, pcm), Region{900 * time.Millisecond, 2 * time.Second})
+}
+
+func TestBlocksMatchDetect(t *testing.T) {
+	pcm := make([]float32, samples(6*time.Second))
+	noise(pcm, -70, 1)
+	voice(pcm, 1000*time.Millisecond, 1500*time.Millisecond)
+	voice(pcm, 3500*time.Millisecond, 4000*time.Millisecond)
+	want := detect(t, pcm)
+
+	detector, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Region
+	for pos, n := 0, 1; pos < len(pcm); n = n*3 + 1 {
+		if n > len(pcm)-pos {
+			n = len(pcm) - pos
+		}
+		got = append(got, detector.Write(pcm[pos:pos+n])...)
+		pos += n
+	}
+	got = append(got, detector.Flush()...)
+	expectRegions(t, got, want...)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Frame = time.Millisecond
+	if _, err := New(config); err == nil {
+		t.Fatal("1ms frames were accepted")
+	}
+}
+
+func TestFFT(t *testing.T) {
+	const size = 64
+	random := rand.New(rand.NewSource(1))
+	x := make([]complex128, size)
+	for i := range x {
+		x[i] = complex(random.Float64()-0.5, random.Float64()-0.5)
+	}
+
+	// Naive DFT
+	want := make([]complex128, size)
+	for k := range want {
+		for n := range x {
+			angle := -2 * math.Pi * float64(k*n) / size
+			want[k] += x[n] * complex(math.Cos(angle), math.Sin(angle))
+		}
+	}
+
+	newFFT(size).transform(x)
+	for k := range x {
+		if d := x[k] - want[k]; math.Hypot(real(d), imag(d)) > 1e-9 {
+			t.Fatalf("bin %d is %v, expected %v", k, x[k], want[k])
+		}
+	}
+}
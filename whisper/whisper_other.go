@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+// Libwhisper can't be created on this platform, every function returns ErrUnsupportedPlatform
+type Libwhisper struct {
+	path string
+	ver  WinVersion
+	caps Capabilities
+}
+
+func New(level eLogLevel, flags eLogFlags, logger Logger, opts ...Option) (*Libwhisper, error) {
+	return nil, errUnsupported("New")
+}
+
+func (this *Libwhisper) SetLogLevel(level eLogLevel) error {
+	return errUnsupported("SetLogLevel")
+}
+
+func (this *Libwhisper) LoadModel(path string, aGPU ...string) (*Model, error) {
+	return nil, errUnsupported("LoadModel")
+}
+
+func (this *Libwhisper) InitMediaFoundation() (*IMediaFoundation, error) {
+	return nil, errUnsupported("InitMediaFoundation")
+}
@@ -0,0 +1,255 @@
+//go:build windows
+// +build windows
+
+package whisper
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+
+	// Using lxn/win because its COM functions expose raw HRESULTs
+	"golang.org/x/sys/windows"
+)
+import (
+	"fmt"
+)
+
+/*
+	eModelImplementation - TranscribeStructs.h
+
+	// GPGPU implementation based on Direct3D 11.0 compute shaders
+	GPU = 1,
+
+	// A hybrid implementation which uses DirectCompute for encode, and decodes on CPU
+	// Not implemented in the published builds of the DLL. To enable, change BUILD_HYBRID_VERSION macro to 1
+	Hybrid = 2,
+
+	// A reference implementation which uses the original GGML CPU-running code
+	// Not implemented in the published builds of the DLL. To enable, change BUILD_BOTH_VERSIONS macro to 1
+	Reference = 3,
+*/
+
+type Libwhisper struct {
+	dll            *syscall.DLL
+	path           string
+	ver            WinVersion
+	caps           Capabilities
+	existing_model map[string]*Model
+
+	logFlags  eLogFlags
+	logToSink bool
+
+	proc_setupLogger         *syscall.Proc
+	proc_loadModel           *syscall.Proc
+	proc_initMediaFoundation *syscall.Proc
+	// proc_findLanguageKeyW      *syscall.Proc
+	// proc_findLanguageKeyA      *syscall.Proc
+	// proc_getSupportedLanguages *syscall.Proc
+}
+
+var singleton_whisper *Libwhisper = nil
+
+// New loads whisper.dll and sets up its logger. The DLL is the first one found in the places of DLLSearch,
+// WithDLLPath sets the path to try first.
+// The messages up to level go to logger, or where flags say when logger is nil.
+func New(level eLogLevel, flags eLogFlags, logger Logger, opts ...Option) (*Libwhisper, error) {
+	if singleton_whisper != nil {
+		return singleton_whisper, nil
+	}
+
+	path, err := dllSearchFor(opts).Resolve(fileExists)
+	if err != nil {
+		return nil, err
+	}
+
+	this := &Libwhisper{path: path}
+
+	this.ver, err = GetFileVersion(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	caps, ok := CapabilitiesFor(this.ver)
+	if !ok {
+		return nil, fmt.Errorf("%s is version %s, this library requires version %s or higher", path, this.ver, MinimumVersion)
+	}
+	this.caps = caps
+
+	// Refuse to pass structs the DLL would misread
+	if err = CheckLayouts(this.ver); err != nil {
+		return nil, err
+	}
+
+	if err = this.load(); err != nil {
+		return nil, err
+	}
+
+	ok, err = this._setupLogger(level, flags, logger)
+	if !ok {
+		this.dll.Release()
+		return nil, errors.New("Logger Error : " + err.Error())
+	}
+
+	this.existing_model = make(map[string]*Model)
+	singleton_whisper = this
+
+	return singleton_whisper, nil
+}
+
+// load loads the DLL and resolves every export up front, so a wrong DLL fails here rather than on the first call
+func (this *Libwhisper) load() error {
+	dll, err := syscall.LoadDLL(this.path)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", this.path, err)
+	}
+
+	procs := map[string]**syscall.Proc{
+		"setupLogger":         &this.proc_setupLogger,
+		"loadModel":           &this.proc_loadModel,
+		"initMediaFoundation": &this.proc_initMediaFoundation,
+	}
+
+	var missing []string
+	for _, name := range requiredExports {
+		proc, err := dll.FindProc(name)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		*procs[name] = proc
+	}
+
+	if len(missing) > 0 {
+		dll.Release()
+		return &MissingExportsError{Path: this.path, Missing: missing}
+	}
+
+	this.dll = dll
+	return nil
+}
+
+// requireFeature returns a FeatureError rather than letting op call into a DLL which lacks the feature
+func requireFeature(op string, feature string, supported func(Capabilities) bool) error {
+	lib := singleton_whisper
+	if lib == nil {
+		return fmt.Errorf("%s: whisper.dll is not loaded", op)
+	}
+	if !supported(lib.caps) {
+		return &FeatureError{Op: op, Feature: feature, Version: lib.ver}
+	}
+	return nil
+}
+
+// The sink of the native logger, created once since callbacks are never freed
+var loggerSinkCallback = newCallback(fnLoggerSink)
+
+func (this *Libwhisper) _setupLogger(level eLogLevel, flags eLogFlags, logger Logger) (bool, error) {
+
+	setup := sLoggerSetup{}
+	setup.sink = 0
+	setup.context = 0
+	setup.level = level
+	setup.flags = flags
+
+	if logger != nil {
+		setup.sink = loggerSinkCallback
+	}
+
+	res, _, err := this.proc_setupLogger.Call(uintptr(unsafe.Pointer(&setup)))
+
+	if windows.Handle(res) == windows.S_OK {
+		setLogger(logger, level)
+		this.logFlags = flags
+		this.logToSink = logger != nil
+		return true, nil
+	} else {
+		return false, err
+	}
+}
+
+// SetLogLevel changes the level of the messages whisper.dll logs, keeping the logger
+func (this *Libwhisper) SetLogLevel(level eLogLevel) error {
+	var logger Logger
+	if this.logToSink {
+		if state := currentLogger.Load(); state != nil {
+			logger = state.logger
+		}
+	}
+
+	if ok, err := this._setupLogger(level, this.logFlags, logger); !ok {
+		return fmt.Errorf("setupLogger failed: %w", err)
+	}
+	return nil
+}
+
+func (this *Libwhisper) LoadModel(path string, aGPU ...string) (*Model, error) {
+	var modelptr *_IModel
+
+	whisperpath, _ := windows.UTF16PtrFromString(path)
+
+	GPU := ""
+	if len(aGPU) == 1 {
+		GPU = aGPU[0]
+	}
+
+	// DLLs without cloneable models load the model again every time
+	flags := gmf_None
+	if this.caps.CloneableModels {
+		flags = gmf_Cloneable
+	}
+	setup := ModelSetup(flags, GPU)
+
+	// Construct our map hash
+	singleton_hash := GPU + "|" + path
+	if this.caps.CloneableModels && this.existing_model[singleton_hash] != nil {
+		ClonedModel, err := this.existing_model[singleton_hash].Clone()
+		if ClonedModel != nil {
+			return NewModel(setup, ClonedModel), nil
+		} else {
+			return nil, err
+		}
+	}
+
+	obj, _, _ := this.proc_loadModel.Call(uintptr(unsafe.Pointer(whisperpath)), uintptr(unsafe.Pointer(setup.AsCType())), uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(&modelptr)))
+
+	if windows.Handle(obj) != windows.S_OK {
+		fmt.Printf("loadModel failed: %s\n", syscall.Errno(obj).Error())
+		return nil, fmt.Errorf("loadModel failed: %s", syscall.Errno(obj))
+	}
+
+	if modelptr == nil {
+		return nil, errors.New("loadModel did not return a Model")
+	}
+
+	if modelptr.lpVtbl == nil {
+		return nil, errors.New("loadModel method table is nil")
+	}
+
+	model := NewModel(setup, modelptr)
+
+	if this.caps.CloneableModels {
+		this.existing_model[singleton_hash] = model
+	}
+
+	return model, nil
+}
+
+func (this *Libwhisper) InitMediaFoundation() (*IMediaFoundation, error) {
+
+	var mediafoundation *IMediaFoundation
+
+	// initMediaFoundation( iMediaFoundation** pp );
+	obj, _, _ := this.proc_initMediaFoundation.Call(uintptr(unsafe.Pointer(&mediafoundation)))
+
+	if windows.Handle(obj) != windows.S_OK {
+		fmt.Printf("initMediaFoundation failed: %s\n", syscall.Errno(obj).Error())
+		return nil, fmt.Errorf("initMediaFoundation failed: %s", syscall.Errno(obj))
+	}
+
+	if mediafoundation.lpVtbl == nil {
+		return nil, errors.New("initMediaFoundation method table is nil")
+	}
+
+	return mediafoundation, nil
+}
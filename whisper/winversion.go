@@ -1,26 +1,9 @@
-// Copyright 2018 Keybase Inc. All rights reserved.
-// Use of this source code is governed by a BSD
-// license that can be found in the LICENSE file.
-// Adapted mainly from github.com/gonutz/w32
-
-//go:build windows
-// +build windows
-
 package whisper
 
 import (
-	"errors"
-	"syscall"
-	"unsafe"
-
-	"golang.org/x/sys/windows"
-)
-
-var (
-	version                = windows.NewLazySystemDLL("version.dll")
-	getFileVersionInfoSize = version.NewProc("GetFileVersionInfoSizeW")
-	getFileVersionInfo     = version.NewProc("GetFileVersionInfoW")
-	verQueryValue          = version.NewProc("VerQueryValueW")
+	"fmt"
+	"strconv"
+	"strings"
 )
 
 type VS_FIXEDFILEINFO struct {
@@ -51,73 +34,50 @@ func (fi VS_FIXEDFILEINFO) FileVersion() uint64 {
 	return uint64(fi.FileVersionMS)<<32 | uint64(fi.FileVersionLS)
 }
 
-func GetFileVersionInfoSize(path string) uint32 {
-	ret, _, _ := getFileVersionInfoSize.Call(
-		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(path))),
-		0,
-	)
-	return uint32(ret)
-}
-
-func GetFileVersionInfo(path string, data []byte) bool {
-	ret, _, _ := getFileVersionInfo.Call(
-		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(path))),
-		0,
-		uintptr(len(data)),
-		uintptr(unsafe.Pointer(&data[0])),
-	)
-	return ret != 0
-}
-
-// VerQueryValueRoot calls VerQueryValue
-// (https://msdn.microsoft.com/en-us/library/windows/desktop/ms647464(v=vs.85).aspx)
-// with `\` (root) to retieve the VS_FIXEDFILEINFO.
-func VerQueryValueRoot(block []byte) (VS_FIXEDFILEINFO, error) {
-	var offset uintptr
-	var length uint
-	blockStart := unsafe.Pointer(&block[0])
-	ret, _, _ := verQueryValue.Call(
-		uintptr(blockStart),
-		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(`\`))),
-		uintptr(unsafe.Pointer(&offset)),
-		uintptr(unsafe.Pointer(&length)),
-	)
-	if ret == 0 {
-		return VS_FIXEDFILEINFO{}, errors.New("VerQueryValueRoot: verQueryValue failed")
-	}
-	start := int(offset) - int(uintptr(blockStart))
-	end := start + int(length)
-	if start < 0 || start >= len(block) || end < start || end > len(block) {
-		return VS_FIXEDFILEINFO{}, errors.New("VerQueryValueRoot: find failed")
+// ParseVersion parses a version of up to four numbers like "1.10" or "1.10.0.0", missing numbers are zero
+func ParseVersion(s string) (WinVersion, error) {
+	var result WinVersion
+	text := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if text == "" {
+		return result, fmt.Errorf("invalid version %q", s)
 	}
-	data := block[start:end]
-	info := *((*VS_FIXEDFILEINFO)(unsafe.Pointer(&data[0])))
-	return info, nil
-}
 
-func GetFileVersion(path string) (WinVersion, error) {
-	var result WinVersion
-	size := GetFileVersionInfoSize(path)
-	if size <= 0 {
-		return result, errors.New("GetFileVersionInfoSize failed")
+	parts := strings.Split(text, ".")
+	if len(parts) > 4 {
+		return result, fmt.Errorf("invalid version %q: more than four numbers", s)
 	}
 
-	info := make([]byte, size)
-	ok := GetFileVersionInfo(path, info)
-	if !ok {
-		return result, errors.New("GetFileVersionInfo failed")
+	fields := []*uint32{&result.Major, &result.Minor, &result.Patch, &result.Build}
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return WinVersion{}, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		*fields[i] = uint32(value)
 	}
+	return result, nil
+}
 
-	fixed, err := VerQueryValueRoot(info)
-	if err != nil {
-		return result, err
+// Compare returns -1, 0 or 1 when the version is older, the same or newer than other
+func (this WinVersion) Compare(other WinVersion) int {
+	a := [4]uint32{this.Major, this.Minor, this.Patch, this.Build}
+	b := [4]uint32{other.Major, other.Minor, other.Patch, other.Build}
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
 	}
-	version := fixed.FileVersion()
+	return 0
+}
 
-	result.Major = uint32(version & 0xFFFF000000000000 >> 48)
-	result.Minor = uint32(version & 0x0000FFFF00000000 >> 32)
-	result.Patch = uint32(version & 0x00000000FFFF0000 >> 16)
-	result.Build = uint32(version & 0x000000000000FFFF)
+// AtLeast is true when the version is min or newer
+func (this WinVersion) AtLeast(min WinVersion) bool {
+	return this.Compare(min) >= 0
+}
 
-	return result, nil
+func (this WinVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", this.Major, this.Minor, this.Patch, this.Build)
 }
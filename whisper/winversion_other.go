@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package whisper
+
+func GetFileVersionInfoSize(path string) uint32 {
+	return 0
+}
+
+func GetFileVersionInfo(path string, data []byte) bool {
+	return false
+}
+
+func VerQueryValueRoot(block []byte) (VS_FIXEDFILEINFO, error) {
+	return VS_FIXEDFILEINFO{}, errUnsupported("VerQueryValueRoot")
+}
+
+func GetFileVersion(path string) (WinVersion, error) {
+	return WinVersion{}, errUnsupported("GetFileVersion")
+}
@@ -0,0 +1,66 @@
+package whisper
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected WinVersion
+		ok       bool
+	}{
+		{"1.10", WinVersion{1, 10, 0, 0}, true},
+		{"v1.10", WinVersion{1, 10, 0, 0}, true},
+		{" 1.9.0.0 ", WinVersion{1, 9, 0, 0}, true},
+		{"2", WinVersion{2, 0, 0, 0}, true},
+		{"1.2.3.4", WinVersion{1, 2, 3, 4}, true},
+		{"1.2.3.4.5", WinVersion{}, false},
+		{"1.x", WinVersion{}, false},
+		{"1..2", WinVersion{}, false},
+		{"-1.0", WinVersion{}, false},
+		{"", WinVersion{}, false},
+		{"v", WinVersion{}, false},
+	}
+
+	for _, c := range cases {
+		ver, err := ParseVersion(c.text)
+		if (err == nil) != c.ok {
+			t.Errorf("ParseVersion(%q) returned error %v", c.text, err)
+			continue
+		}
+		if ver != c.expected {
+			t.Errorf("ParseVersion(%q) = %v, expected %v", c.text, ver, c.expected)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b     WinVersion
+		expected int
+	}{
+		{WinVersion{1, 9, 0, 0}, WinVersion{1, 9, 0, 0}, 0},
+		{WinVersion{1, 10, 0, 0}, WinVersion{1, 9, 0, 0}, 1},
+		{WinVersion{0, 9, 0, 0}, WinVersion{1, 9, 0, 0}, -1},
+		{WinVersion{2, 0, 0, 0}, WinVersion{1, 10, 0, 0}, 1},
+		{WinVersion{1, 9, 0, 1}, WinVersion{1, 9, 1, 0}, -1},
+	}
+
+	for _, c := range cases {
+		if got := c.a.Compare(c.b); got != c.expected {
+			t.Errorf("%v.Compare(%v) = %d, expected %d", c.a, c.b, got, c.expected)
+		}
+		if got := c.b.Compare(c.a); got != -c.expected {
+			t.Errorf("%v.Compare(%v) = %d, expected %d", c.b, c.a, got, -c.expected)
+		}
+		if c.a.AtLeast(c.b) != (c.expected >= 0) {
+			t.Errorf("%v.AtLeast(%v) is %v", c.a, c.b, c.a.AtLeast(c.b))
+		}
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	lib := &Libwhisper{ver: WinVersion{1, 10, 2, 0}}
+	if got := lib.Version(); got != "1.10.2.0" {
+		t.Fatalf("Version() = %q", got)
+	}
+}